@@ -9,6 +9,7 @@
  <tile id="1">
   <properties>
    <property name="color" value="blue"/>
+   <property name="solid" type="bool" value="true"/>
   </properties>
   <image width="32" height="32" source="square_colors/blue.png"/>
  </tile>
@@ -27,6 +28,7 @@
  <tile id="4">
   <properties>
    <property name="color" value="red"/>
+   <property name="hazard" type="bool" value="true"/>
   </properties>
   <image width="32" height="32" source="square_colors/red.png"/>
  </tile>
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"flag"
+
 	game "moonlander/src"
+	sha "moonlander/src/shared"
 )
 
 func main() {
-	game.Start()
+	replay := flag.String("replay", "", "play a replay file")
+	flag.BoolVar(&sha.Debug, "debug", false, "steer the tester object with T, F, G, H and R, Y")
+	flag.Parse()
+	game.Start(*replay)
 }
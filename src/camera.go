@@ -3,6 +3,7 @@ package src
 import (
 	"fmt"
 	"math"
+	com "moonlander/src/components"
 	sha "moonlander/src/shared"
 
 	"github.com/hajimehoshi/ebiten"
@@ -71,13 +72,17 @@ func (c *Camera) Reset() {
 	c.Position[1] = 0
 	c.Rotation = 0
 	c.ZoomFactor = 0
-	c.boundRight = float64(sha.LP.Width) - float64(sha.ScreenWidth)
-	c.boundBottom = float64(sha.LP.Height) - float64(sha.ScreenHeight)
-	fmt.Println(sha.LP.Width, sha.LP.Height, sha.ScreenWidth, sha.ScreenHeight)
 }
 
-// Update ..
-func (c *Camera) Update() error {
+// SetBounds keeps the camera in a level of width x height
+func (c *Camera) SetBounds(width, height int) {
+	c.boundRight = float64(width) - float64(sha.ScreenWidth)
+	c.boundBottom = float64(height) - float64(sha.ScreenHeight)
+	fmt.Println(width, height, sha.ScreenWidth, sha.ScreenHeight)
+}
+
+// Update .., the camera follows the player (when there is one)
+func (c *Camera) Update(player *com.Player) error {
 	panCamera := false
 
 	// pan WSAD
@@ -99,7 +104,7 @@ func (c *Camera) Update() error {
 	}
 
 	// center camera view on player, unless panning
-	if !panCamera {
+	if !panCamera && player != nil {
 		c.Position[0] = player.X - float64(sha.ScreenWidth/2)
 		c.Position[1] = player.Y - float64(sha.ScreenHeight/2)
 		//fmt.Println(&player.X, &player.Y)
@@ -0,0 +1,88 @@
+package src
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sha "moonlander/src/shared"
+
+	"github.com/lafriks/go-tiled"
+)
+
+// directory with the Tiled maps, every tmx file in it is a level
+const levelDir = "assets/tiled"
+
+var (
+	levels []sha.LevelInfo
+)
+
+// ScanLevels (re)builds the level catalogue from the maps in the level directory,
+// sorted by the map property order, maps which can't be parsed are skipped with a warning
+func ScanLevels() []sha.LevelInfo {
+	levels = nil
+	files, err := ioutil.ReadDir(levelDir)
+	if err != nil {
+		fmt.Printf("warning: can't read level directory: %v\n", err)
+		return nil
+	}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".tmx" {
+			continue
+		}
+		path := filepath.Join(levelDir, f.Name())
+		m, err := tiled.LoadFromFile(path)
+		if err != nil {
+			fmt.Printf("warning: %v: skipped in level catalogue: %v\n", path, err)
+			continue
+		}
+		levels = append(levels, getLevelInfo(path, m))
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		if levels[i].Order != levels[j].Order {
+			return levels[i].Order < levels[j].Order
+		}
+		return levels[i].ID < levels[j].ID
+	})
+	return levels
+}
+
+// getLevel returns a level from the catalogue by id
+func getLevel(id string) (sha.LevelInfo, bool) {
+	for _, l := range levels {
+		if l.ID == id {
+			return l, true
+		}
+	}
+	return sha.LevelInfo{}, false
+}
+
+// nextLevel returns the level after a level in the catalogue
+func nextLevel(id string) (sha.LevelInfo, bool) {
+	for i, l := range levels {
+		if l.ID == id && i+1 < len(levels) {
+			return levels[i+1], true
+		}
+	}
+	return sha.LevelInfo{}, false
+}
+
+// getLevelInfo reads the catalogue info from the map properties, the id is the file name without extension
+func getLevelInfo(path string, m *tiled.Map) sha.LevelInfo {
+	id := strings.TrimSuffix(filepath.Base(path), ".tmx")
+	info := sha.LevelInfo{ID: id, Path: path, Title: id}
+	if m.Properties != nil {
+		if title := m.Properties.GetString("title"); title != "" {
+			info.Title = title
+		}
+		info.Order = m.Properties.GetInt("order")
+		info.Difficulty = m.Properties.GetString("difficulty")
+		info.Author = m.Properties.GetString("author")
+		info.Gold = m.Properties.GetFloat("gold")
+		info.Silver = m.Properties.GetFloat("silver")
+		info.Bronze = m.Properties.GetFloat("bronze")
+	}
+	return info
+}
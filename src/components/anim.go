@@ -43,7 +43,13 @@ func NewAnimFromByte(img []byte, x, y, z int, v Vector, frame Frame) Anim {
 
 // Draw implements interface
 func (o *Anim) Draw(screen *ebiten.Image) error {
+	return o.drawAlpha(screen, 1)
+}
+
+// drawAlpha draws the current frame with an alpha (0 - 1), for translucent animations
+func (o *Anim) drawAlpha(screen *ebiten.Image, alpha float64) error {
 	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(1, 1, 1, alpha)
 
 	op.GeoM.Translate(-float64(o.frame.w)/2, -float64(o.frame.h)/2)
 	op.GeoM.Rotate(o.R)
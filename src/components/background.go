@@ -2,7 +2,6 @@ package com
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
@@ -15,10 +14,10 @@ type Background struct {
 }
 
 // NewBackground constructor
-func NewBackground(id int, imagePath string, x, y, z, w, h int, v Vector) Background {
+func NewBackground(id int, imagePath string, x, y, z, w, h int, v Vector) (Background, error) {
 	img, _, err := ebitenutil.NewImageFromFile(imagePath, ebiten.FilterDefault)
 	if err != nil {
-		log.Fatal(err)
+		return Background{}, err
 	}
 	wImg, hImg := img.Size()
 	scaleX := float64(float64(w) / float64(wImg))
@@ -28,7 +27,7 @@ func NewBackground(id int, imagePath string, x, y, z, w, h int, v Vector) Backgr
 		Sprite: NewSprite(id, img, x, y, z, v),
 		scaleX: scaleX,
 		scaleY: scaleY,
-	}
+	}, nil
 }
 
 // Draw implements interface
@@ -4,6 +4,8 @@ import (
 	"image/color"
 	"math"
 
+	sha "moonlander/src/shared"
+
 	"github.com/hajimehoshi/ebiten"
 )
 
@@ -14,6 +16,9 @@ const (
 	HPI      float64 = math.Pi / 2
 	RadToDeg float64 = 180 / math.Pi
 	DegToRad float64 = math.Pi / 180
+	// the ticks per second the game was tuned at, the per tick values of the maps
+	// (gravity, friction, velocity) are converted to per second with it
+	tuneTPS float64 = 60
 )
 
 // GameObject interface for all game objects
@@ -22,11 +27,28 @@ type GameObject interface {
 	GetInfo() (id int, name string, x, y, r float64, w, h int)
 	Draw(screen *ebiten.Image) error
 	Update(screen *ebiten.Image) error
-	SetHit(collider GameObject)
 	GetObject() *Object
 	Collide(hitList []GameObject) error
 }
 
+// ZOrdered is implemented by objects which are drawn in a z order (all Sprites),
+// objects with a higher z are drawn on top
+type ZOrdered interface {
+	GetZ() int
+	SetZ(z int)
+}
+
+// draw layers (z) of the kinds of objects
+const (
+	ZBackground int = -100
+	ZTerrain    int = 0
+	ZTrigger    int = 10
+	ZDebris     int = 20
+	ZShip       int = 30
+	ZParticles  int = 40
+	ZForeground int = 100
+)
+
 // Vector used for direction of objects
 type Vector struct {
 	x, y float64
@@ -37,6 +59,50 @@ func NewVector(x, y float64) Vector {
 	return Vector{x, y}
 }
 
+// XY returns the x and y of a Vector
+func (v Vector) XY() (float64, float64) {
+	return v.x, v.y
+}
+
+func (v Vector) add(o Vector) Vector {
+	return Vector{v.x + o.x, v.y + o.y}
+}
+
+func (v Vector) sub(o Vector) Vector {
+	return Vector{v.x - o.x, v.y - o.y}
+}
+
+func (v Vector) scale(s float64) Vector {
+	return Vector{v.x * s, v.y * s}
+}
+
+func (v Vector) dot(o Vector) float64 {
+	return v.x*o.x + v.y*o.y
+}
+
+func (v Vector) length() float64 {
+	return math.Hypot(v.x, v.y)
+}
+
+// perp returns the vector rotated 90 degrees
+func (v Vector) perp() Vector {
+	return Vector{-v.y, v.x}
+}
+
+// damp returns the damping factor of one tick, f is the damping factor of a tick at tuneTPS
+func damp(f float64) float64 {
+	return math.Pow(f, tuneTPS*sha.DT)
+}
+
+// normalize returns the unit vector, or a zero vector
+func (v Vector) normalize() Vector {
+	l := v.length()
+	if l == 0 {
+		return Vector{}
+	}
+	return Vector{v.x / l, v.y / l}
+}
+
 //Rect as format x,y,w,h
 type Rect struct {
 	x, y, w, h int
@@ -53,12 +119,22 @@ func (r *Rect) setXY(x, y int) {
 	r.y = y
 }
 
+// points returns the corners of the Rect (clockwise, from top left)
+func (r *Rect) points() []Vector {
+	x, y, w, h := float64(r.x), float64(r.y), float64(r.w), float64(r.h)
+	return []Vector{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
+}
+
 // HitShape is used for custom hit area for objects
 type HitShape struct {
 	rx, ry    int
 	rect      Rect
+	shape     []Vector
+	moved     Vector
 	rectImg   *ebiten.Image
 	rectColor color.RGBA
 	Hit       bool
 	solid     bool
+	// collision layer of the object, and the layers it collides with
+	layer, mask Layer
 }
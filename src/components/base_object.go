@@ -2,6 +2,7 @@ package com
 
 import (
 	"image/color"
+	"math"
 
 	sha "moonlander/src/shared"
 
@@ -13,9 +14,12 @@ type Object struct {
 	Sprite
 	HitShape
 	debug bool
+	// contacts found by Collide this tick, collected by the ContactManager
+	contacts []ContactEvent
 }
 
 // NewObject creates new Object from source image, use hx, hy, w, h to position and size the hitbox,
+// solid objects are on the terrain layer, others on the trigger layer. Both collide with nothing, use SetLayers
 func NewObject(id int, img *ebiten.Image, x, y, z int, v Vector, rx, ry, rw, rh int, solid bool, c color.RGBA) Object {
 
 	// hit rect image (centered in image)
@@ -36,6 +40,7 @@ func NewObject(id int, img *ebiten.Image, x, y, z int, v Vector, rx, ry, rw, rh
 			rectImg:   rectImg,
 			rectColor: c,
 			solid:     solid,
+			layer:     getDefaultLayer(solid),
 		},
 		debug: false,
 	}
@@ -56,6 +61,7 @@ func (o *Object) GetInfo() (id int, name string, x, y, r float64, w, h int) {
 func (o *Object) Draw(screen *ebiten.Image) error {
 	if o.Img != nil {
 		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Rotate(o.R)
 		op.GeoM.Translate(o.X, o.Y)
 		screen.DrawImage(o.Img, op)
 	}
@@ -63,7 +69,13 @@ func (o *Object) Draw(screen *ebiten.Image) error {
 		// only draw hit rect, when it gets a hit tag
 		if o.Hit {
 			op := &ebiten.DrawImageOptions{}
-			op.GeoM.Translate(float64(o.rect.x), float64(o.rect.y))
+			if o.shape != nil {
+				op.GeoM.Translate(float64(o.rx), float64(o.ry))
+				op.GeoM.Rotate(o.R)
+				op.GeoM.Translate(o.X, o.Y)
+			} else {
+				op.GeoM.Translate(float64(o.rect.x), float64(o.rect.y))
+			}
 			screen.DrawImage(o.rectImg, op)
 		}
 	}
@@ -72,8 +84,9 @@ func (o *Object) Draw(screen *ebiten.Image) error {
 
 // Update implements interface
 func (o *Object) Update(screen *ebiten.Image) error {
-	o.X += o.Vector.x
-	o.Y += o.Vector.y
+	o.moved = o.Vector.scale(sha.DT)
+	o.X += o.moved.x
+	o.Y += o.moved.y
 	o.rect.setXY(int(o.X)+o.rx, int(o.Y)+o.ry)
 	return nil
 }
@@ -83,15 +96,132 @@ func (o *Object) GetObject() *Object {
 	return o
 }
 
-// SetHit implements interface
-func (o *Object) SetHit(collider GameObject) {
-}
-
 // Collide implements interface
 func (o *Object) Collide(hitAbles []GameObject) error {
 	return nil
 }
 
+// SetRotation rotates the object (image and hit rect) around its top left corner like Tiled does,
+// the rotated hit rect becomes the hit shape and the hit rect its bounding box.
+// Only for objects which don't move, Update resets the hit rect
+func (o *Object) SetRotation(rad float64) {
+	o.R = rad
+	o.shape = nil
+	if rad != 0 {
+		o.rotateShape(o.X, o.Y, rad, o.rect.w, o.rect.h)
+	}
+}
+
+// rotateShape sets the hit shape to an oriented box, the w x h hit box (at the hit offset)
+// rotated around px, py. The hit rect becomes the bounding box of the shape
+func (o *Object) rotateShape(px, py, rad float64, w, h int) {
+	x, y := o.X+float64(o.rx), o.Y+float64(o.ry)
+	box := []Vector{{x, y}, {x + float64(w), y}, {x + float64(w), y + float64(h)}, {x, y + float64(h)}}
+	o.shape = o.shape[:0]
+	for _, p := range box {
+		rx, ry := GetRotatedPoint(px, py, p.x-px, p.y-py, rad)
+		o.shape = append(o.shape, Vector{rx, ry})
+	}
+	o.rect = getBounds(o.shape)
+}
+
+// getPolygon returns the hit shape, or the hit rect when the object has no shape
+func (o *Object) getPolygon() []Vector {
+	if o.shape != nil {
+		return o.shape
+	}
+	return o.rect.points()
+}
+
+// GetShapes implements Shaped, only rotated objects have a shape
+func (o *Object) GetShapes() [][]Vector {
+	if o.shape == nil {
+		return nil
+	}
+	return [][]Vector{o.shape}
+}
+
+// pushOut moves the object (and its hit shape) out of a shape it hits
+func (o *Object) pushOut(c Contact) {
+	o.translate(c.Normal.scale(c.Depth))
+}
+
+// addContact records a contact with a target, for the ContactManager
+func (o *Object) addContact(target GameObject, c Contact) {
+	o.contacts = append(o.contacts, ContactEvent{Other: target, Contact: c})
+}
+
+// sweep moves the object back along its last move, to where it first touched a solid target,
+// so fast objects can't pass through thin objects. Returns the target and the contact normal
+func (o *Object) sweep(hitAbles []GameObject) (*Object, Vector, bool) {
+	if o.moved == (Vector{}) {
+		return nil, Vector{}, false
+	}
+	var first *Object
+	var normal Vector
+	toi := 1.0
+	for _, h := range hitAbles {
+		t := h.GetObject()
+		if t == o || !t.solid {
+			continue
+		}
+		if hit, ht, n := SweepShapes(o, t, GetHitShapes(h)); hit && ht < toi {
+			first, normal, toi = t, n, ht
+		}
+	}
+	if first == nil {
+		return nil, Vector{}, false
+	}
+	o.translate(o.moved.scale(toi - 1))
+	o.moved = o.moved.scale(toi)
+	return first, normal, true
+}
+
+// translate moves the object and its hit shape (or rect)
+func (o *Object) translate(move Vector) {
+	o.X += move.x
+	o.Y += move.y
+	if o.shape != nil {
+		for i := range o.shape {
+			o.shape[i] = o.shape[i].add(move)
+		}
+		o.rect = getBounds(o.shape)
+	} else {
+		o.rect.setXY(int(o.X)+o.rx, int(o.Y)+o.ry)
+	}
+}
+
+// sweptBounds returns the bounding box of the hit rect over its last move
+func (o *Object) sweptBounds() Rect {
+	r := o.rect
+	r.x, r.y = r.x-int(math.Ceil(math.Max(o.moved.x, 0))), r.y-int(math.Ceil(math.Max(o.moved.y, 0)))
+	r.w, r.h = r.w+int(math.Ceil(math.Abs(o.moved.x))), r.h+int(math.Ceil(math.Abs(o.moved.y)))
+	return r
+}
+
+// SetLayers sets the collision layer of the object, and the layers it collides with
+func (o *Object) SetLayers(layer, mask Layer) {
+	o.layer = layer
+	o.mask = mask
+}
+
+// GetLayers returns the collision layer and mask of the object
+func (o *Object) GetLayers() (layer, mask Layer) {
+	return o.layer, o.mask
+}
+
+// CanCollide returns if the object collides with the target, when the layer of the target is in its mask
+func (o *Object) CanCollide(target *Object) bool {
+	return o.mask&target.layer != 0
+}
+
+func getDefaultLayer(solid bool) Layer {
+	if solid {
+		return LayerTerrain
+	}
+	return LayerTrigger
+}
+
 // GetRect returns the object hitshape rect
 func (o *Object) GetRect() *Rect {
 	return &o.rect
@@ -11,13 +11,14 @@ type Sprite struct {
 	ID      int
 	Img     *ebiten.Image
 	X, Y, R float64
+	Z       int
 	Vector  Vector
 }
 
 // NewSprite creates a Sprite
-// a Sprite can be drawn and optionally updated, sprites have no collision
+// a Sprite can be drawn and optionally updated, sprites have no collision. z is the draw layer
 func NewSprite(id int, img *ebiten.Image, x, y, z int, v Vector) Sprite {
-	return Sprite{id, img, float64(x), float64(y), float64(z), v}
+	return Sprite{ID: id, Img: img, X: float64(x), Y: float64(y), Z: z, Vector: v}
 }
 
 // GetID implements interface
@@ -31,6 +32,16 @@ func (o *Sprite) GetInfo() (id int, name string, x, y, r float64, w, h int) {
 	return o.ID, sha.Name[o.ID], o.X, o.Y, o.R, w, h
 }
 
+// GetZ implements ZOrdered
+func (o *Sprite) GetZ() int {
+	return o.Z
+}
+
+// SetZ implements ZOrdered
+func (o *Sprite) SetZ(z int) {
+	o.Z = z
+}
+
 // Draw implements interface
 func (o *Sprite) Draw(screen *ebiten.Image) error {
 	op := &ebiten.DrawImageOptions{}
@@ -51,10 +62,6 @@ func (o *Sprite) GetObject() *Object {
 	return nil
 }
 
-// SetHit implements interface
-func (o *Sprite) SetHit(collider GameObject) {
-}
-
 // Collide implements interface
 func (o *Sprite) Collide(hitAbles []GameObject) error {
 	return nil
@@ -2,24 +2,53 @@ package com
 
 import (
 	"image/color"
+
+	sha "moonlander/src/shared"
 )
 
-// Checkpoint is <dunno yet>
+// Checkpoint has to be passed in a lap, checkpoints are passed in their order
+// (checkpoints with the same order in any order)
 type Checkpoint struct {
 	Object
-	done bool
+	Order  int
+	done   bool
+	finish *Finish
+}
+
+func init() {
+	Register("cp", func(a ItemArgs) (GameObject, error) {
+		o := NewCheckpoint(sha.IDCheckpoint, a.X, a.Y, a.W, a.H, a.Props.GetInt("order", 0), a.Props.GetColor("color", sha.Cyan25), true)
+		o.SetRotation(a.Rotation * DegToRad)
+		return &o, nil
+	})
 }
 
 // NewCheckpoint constructor
-func NewCheckpoint(id, x, y, w, h int, c color.RGBA, done bool) Checkpoint {
+func NewCheckpoint(id, x, y, w, h, order int, c color.RGBA, done bool) Checkpoint {
 	return Checkpoint{
-		Object: NewObject(id, nil, x, y, 0, Vector{}, 0, 0, w, h, false, c),
+		Object: NewObject(id, nil, x, y, ZTrigger, Vector{}, 0, 0, w, h, false, c),
+		Order:  order,
 		done:   done,
 	}
 }
 
-// SetHit Override
-func (o *Checkpoint) SetHit(collider GameObject) {
-	o.Hit = true
+// OnEnter implements ContactListener, the checkpoint is passed when the checkpoints before it are,
+// the finish records the split time
+func (o *Checkpoint) OnEnter(e ContactEvent) {
+	if o.done || (o.finish != nil && !o.finish.canPass(o)) {
+		return
+	}
 	o.done = true
+	if o.finish != nil {
+		o.finish.split(o)
+	}
+}
+
+// OnStay implements ContactListener
+func (o *Checkpoint) OnStay(e ContactEvent) {
+}
+
+// OnExit implements ContactListener, a passed checkpoint stays highlighted
+func (o *Checkpoint) OnExit(e ContactEvent) {
+	o.Hit = o.done
 }
@@ -0,0 +1,118 @@
+package com
+
+// ContactEvent is a contact between two objects, seen from Self,
+// the contact normal points from Other to Self
+type ContactEvent struct {
+	Self, Other GameObject
+	Contact     Contact
+}
+
+// ContactListener is implemented by objects which want to know when they touch other objects,
+// OnEnter is called on the first tick of a contact, OnStay on every next tick and OnExit when it ends
+type ContactListener interface {
+	OnEnter(e ContactEvent)
+	OnStay(e ContactEvent)
+	OnExit(e ContactEvent)
+}
+
+// pair of a collider and a target
+type contactPair struct {
+	collider, target GameObject
+}
+
+// ContactManager tracks the contacts of colliders across ticks, and sends the contact events to both
+// objects of a contact (when they are a ContactListener) and to the subscribers (as seen by the collider).
+// It also sets the hit highlight of every target which is touched
+type ContactManager struct {
+	pairs       []contactPair
+	contacts    map[contactPair]Contact
+	subscribers []ContactListener
+}
+
+// NewContactManager constructor
+func NewContactManager() *ContactManager {
+	return &ContactManager{contacts: make(map[contactPair]Contact)}
+}
+
+// Subscribe adds a listener for the events of all contacts, like sound cues
+func (m *ContactManager) Subscribe(l ContactListener) {
+	m.subscribers = append(m.subscribers, l)
+}
+
+// Update collects the contacts the colliders found this tick, and sends the events.
+// Exits are sent first, then enters and stays, in the order the contacts were found
+func (m *ContactManager) Update(colliders []GameObject) {
+	var pairs []contactPair
+	contacts := make(map[contactPair]Contact)
+	for _, c := range colliders {
+		o := c.GetObject()
+		for _, e := range o.contacts {
+			p := contactPair{c, e.Other}
+			if _, ok := contacts[p]; !ok {
+				pairs = append(pairs, p)
+			}
+			// the deepest contact of a pair (a target can have more shapes)
+			if old, ok := contacts[p]; !ok || e.Contact.Depth > old.Depth {
+				contacts[p] = e.Contact
+			}
+		}
+		o.contacts = o.contacts[:0]
+	}
+
+	// highlight what is touched, before the events so listeners can change it
+	for _, p := range m.pairs {
+		p.target.GetObject().Hit = false
+	}
+	for _, p := range pairs {
+		p.target.GetObject().Hit = true
+	}
+
+	for _, p := range m.pairs {
+		if _, ok := contacts[p]; !ok {
+			m.send(p, m.contacts[p], ContactListener.OnExit)
+		}
+	}
+	for _, p := range pairs {
+		if _, ok := m.contacts[p]; ok {
+			m.send(p, contacts[p], ContactListener.OnStay)
+		} else {
+			m.send(p, contacts[p], ContactListener.OnEnter)
+		}
+	}
+	m.pairs, m.contacts = pairs, contacts
+}
+
+// Remove ends the contacts of an object which is removed from the world, the exits are sent right away
+func (m *ContactManager) Remove(o GameObject) {
+	pairs := m.pairs[:0]
+	for _, p := range m.pairs {
+		if p.collider != o && p.target != o {
+			pairs = append(pairs, p)
+			continue
+		}
+		p.target.GetObject().Hit = false
+		m.send(p, m.contacts[p], ContactListener.OnExit)
+		delete(m.contacts, p)
+	}
+	m.pairs = pairs
+}
+
+// Clear forgets all contacts, without sending exits
+func (m *ContactManager) Clear() {
+	m.pairs = nil
+	m.contacts = make(map[contactPair]Contact)
+}
+
+// send an event to the collider, the target and the subscribers
+func (m *ContactManager) send(p contactPair, c Contact, event func(ContactListener, ContactEvent)) {
+	e := ContactEvent{Self: p.collider, Other: p.target, Contact: c}
+	if l, ok := p.collider.(ContactListener); ok {
+		event(l, e)
+	}
+	if l, ok := p.target.(ContactListener); ok {
+		event(l, ContactEvent{Self: p.target, Other: p.collider, Contact: Contact{Normal: c.Normal.scale(-1), Depth: c.Depth}})
+	}
+	for _, l := range m.subscribers {
+		event(l, e)
+	}
+}
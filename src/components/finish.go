@@ -2,7 +2,6 @@ package com
 
 import (
 	"image/color"
-	"time"
 	sha "moonlander/src/shared"
 )
 
@@ -11,18 +10,28 @@ type Finish struct {
 	Object
 	Checkpoints []*Checkpoint
 	finished    bool
+	lp          *sha.LevelProperties
 }
 
-// NewFinish constructor
-func NewFinish(id, x, y, w, h int, c color.RGBA, checkpoints []*Checkpoint) Finish {
+func init() {
+	Register("finish", func(a ItemArgs) (GameObject, error) {
+		o := NewFinish(sha.IDFinish, a.X, a.Y, a.W, a.H, a.Props.GetColor("color", sha.White25), nil, a.Level)
+		o.SetRotation(a.Rotation * DegToRad)
+		return &o, nil
+	})
+}
+
+// NewFinish constructor, the laps are kept in the level properties lp
+func NewFinish(id, x, y, w, h int, c color.RGBA, checkpoints []*Checkpoint, lp *sha.LevelProperties) Finish {
 	return Finish{
-		Object:      NewObject(id, nil, x, y, 0, Vector{}, 0, 0, w, h, false, c),
+		Object:      NewObject(id, nil, x, y, ZTrigger, Vector{}, 0, 0, w, h, false, c),
 		Checkpoints: checkpoints,
+		lp:          lp,
 	}
 }
 
-// SetHit Override
-func (o *Finish) SetHit(collider GameObject) {
+// OnEnter implements ContactListener, a lap is done when all checkpoints are passed
+func (o *Finish) OnEnter(e ContactEvent) {
 
 	if !o.finished {
 
@@ -37,18 +46,20 @@ func (o *Finish) SetHit(collider GameObject) {
 
 		// valid lap
 		if allHit {
-			// save duration's of laps
-			if !sha.LP.LapStartTime.IsZero() {
-				duration := time.Now().Sub(sha.LP.LapStartTime)
-				sha.LP.LapTimes = append(sha.LP.LapTimes, duration)
+			// save ticks and split times of laps
+			if o.lp.LapStarted {
+				o.lp.LapTicks = append(o.lp.LapTicks, o.lp.Clock.Tick-o.lp.LapStartTick)
+				o.lp.LapSplits = append(o.lp.LapSplits, o.lp.Splits)
 			}
+			o.lp.Splits = o.newSplits()
 
-			// set startTime of lap
-			sha.LP.LapStartTime = time.Now()
+			// set start tick of lap
+			o.lp.LapStartTick = o.lp.Clock.Tick
+			o.lp.LapStarted = true
 
 			// increase currentLap
-			sha.LP.CurrentLap++
-			if sha.LP.CurrentLap > sha.LP.MaxLaps {
+			o.lp.CurrentLap++
+			if o.lp.CurrentLap > o.lp.MaxLaps {
 				o.finished = true
 			}
 
@@ -61,3 +72,56 @@ func (o *Finish) SetHit(collider GameObject) {
 	}
 
 }
+
+// SetCheckpoints sets the checkpoints which have to be passed in a lap
+func (o *Finish) SetCheckpoints(checkpoints []*Checkpoint) {
+	o.Checkpoints = checkpoints
+	for _, cp := range checkpoints {
+		cp.finish = o
+	}
+}
+
+// canPass checks if all checkpoints before a checkpoint are passed
+func (o *Finish) canPass(c *Checkpoint) bool {
+	for _, cp := range o.Checkpoints {
+		if cp.Order < c.Order && !cp.done {
+			return false
+		}
+	}
+	return true
+}
+
+// newSplits returns the split times of a new lap, -1 for every checkpoint
+func (o *Finish) newSplits() []int {
+	s := make([]int, len(o.Checkpoints))
+	for i := range s {
+		s[i] = -1
+	}
+	return s
+}
+
+// split records the split time of a passed checkpoint, the ticks since the start of the lap,
+// at the index of the checkpoint (checkpoints with the same order can be passed in any order)
+func (o *Finish) split(c *Checkpoint) {
+	if !o.lp.LapStarted || o.finished {
+		return
+	}
+	for i, cp := range o.Checkpoints {
+		if cp == c && i < len(o.lp.Splits) {
+			o.lp.Splits[i] = o.lp.Clock.Tick - o.lp.LapStartTick
+		}
+	}
+}
+
+// Finished returns true when all laps are done
+func (o *Finish) Finished() bool {
+	return o.finished
+}
+
+// OnStay implements ContactListener
+func (o *Finish) OnStay(e ContactEvent) {
+}
+
+// OnExit implements ContactListener
+func (o *Finish) OnExit(e ContactEvent) {
+}
@@ -0,0 +1,53 @@
+package com
+
+import (
+	"image/color"
+	"testing"
+
+	sha "moonlander/src/shared"
+)
+
+// splitsAfter passes the checkpoints with the given indexes, one every 10 ticks after the lap start,
+// and returns the splits of the lap
+func splitsAfter(pass ...int) []int {
+	lp := &sha.LevelProperties{MaxLaps: 3}
+	var cps []*Checkpoint
+	for _, order := range []int{1, 2, 2, 3} {
+		cp := NewCheckpoint(0, 0, 0, 10, 10, order, color.RGBA{}, true)
+		cps = append(cps, &cp)
+	}
+	f := NewFinish(0, 0, 0, 10, 10, color.RGBA{}, nil, lp)
+	f.SetCheckpoints(cps)
+	f.OnEnter(ContactEvent{})
+	for _, i := range pass {
+		lp.Clock.Tick += 10
+		cps[i].OnEnter(ContactEvent{})
+	}
+	return lp.Splits
+}
+
+func TestSplitsPerCheckpoint(t *testing.T) {
+	for _, tt := range []struct {
+		pass []int
+		want []int
+	}{
+		{[]int{0, 1, 2, 3}, []int{10, 20, 30, 40}},
+		// the checkpoints with the same order keep their splits when passed the other way around
+		{[]int{0, 2, 1, 3}, []int{10, 30, 20, 40}},
+		// a checkpoint passed before the ones before it doesn't count
+		{[]int{3, 0, 1}, []int{20, 30, -1, -1}},
+		{nil, []int{-1, -1, -1, -1}},
+	} {
+		got := splitsAfter(tt.pass...)
+		if len(got) != len(tt.want) {
+			t.Errorf("pass %v: splits %v, want %v", tt.pass, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("pass %v: splits %v, want %v", tt.pass, got, tt.want)
+				break
+			}
+		}
+	}
+}
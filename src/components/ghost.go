@@ -0,0 +1,86 @@
+package com
+
+import (
+	sha "moonlander/src/shared"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// GhostFrame is the position, rotation and packed controls of a ship in one tick
+type GhostFrame struct {
+	X, Y, R  float64
+	Controls byte
+}
+
+// alpha of a ghost
+const ghostAlpha = 0.35
+
+// Ghost is a translucent ship which flies a recorded lap, it starts when a lap starts.
+// It has no collision
+type Ghost struct {
+	thrusters
+	trace        []GhostFrame
+	frame        GhostFrame
+	visible      bool
+	hidden       bool
+	imgHW, imgHH float64
+	lp           *sha.LevelProperties
+	Sprite
+}
+
+// NewGhost constructor, trace is a frame per tick of the lap, lp are the properties of the level
+func NewGhost(trace []GhostFrame, lp *sha.LevelProperties) (Ghost, error) {
+	img, _, err := ebitenutil.NewImageFromFile("assets/spaceship.png", ebiten.FilterDefault)
+	if err != nil {
+		return Ghost{}, err
+	}
+	w, h := img.Size()
+	return Ghost{
+		thrusters: newThrusters(),
+		trace:     trace,
+		imgHW:     float64(w / 2),
+		imgHH:     float64(h / 2),
+		lp:        lp,
+		// just below the player
+		Sprite: NewSprite(sha.IDGhost, img, 0, 0, ZShip-1, Vector{}),
+	}, nil
+}
+
+// SetTrace replaces the lap the ghost flies, like a new best lap
+func (o *Ghost) SetTrace(trace []GhostFrame) {
+	o.trace = trace
+}
+
+// SetHidden hides or shows the ghost, a hidden ghost still flies
+func (o *Ghost) SetHidden(hidden bool) {
+	o.hidden = hidden
+}
+
+// Update implements interface, moves to the frame of the current tick of the lap
+func (o *Ghost) Update(screen *ebiten.Image) error {
+	i := o.lp.Clock.Tick - o.lp.LapStartTick
+	o.visible = o.lp.LapStarted && o.lp.CurrentLap <= o.lp.MaxLaps && i >= 0 && i < len(o.trace)
+	if !o.visible {
+		return nil
+	}
+	o.frame = o.trace[i]
+	o.X, o.Y, o.R = o.frame.X, o.frame.Y, o.frame.R
+	o.thrusters.update(screen, ControlsFromBits(o.frame.Controls), o.X+o.imgHW, o.Y+o.imgHH, o.imgHW, o.imgHH, o.R)
+	return nil
+}
+
+// Draw implements interface
+func (o *Ghost) Draw(screen *ebiten.Image) error {
+	if !o.visible || o.hidden {
+		return nil
+	}
+	o.thrusters.draw(screen, ControlsFromBits(o.frame.Controls), ghostAlpha)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-o.imgHW, -o.imgHH)
+	op.GeoM.Rotate(o.R)
+	op.GeoM.Translate(o.X+o.imgHW, o.Y+o.imgHH)
+	op.ColorM.Scale(1, 1, 1, ghostAlpha)
+	screen.DrawImage(o.Img, op)
+	return nil
+}
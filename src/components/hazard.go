@@ -0,0 +1,40 @@
+package com
+
+import (
+	"image/color"
+
+	sha "moonlander/src/shared"
+)
+
+// Hazard is an area which resets the player when hit
+type Hazard struct {
+	Object
+}
+
+func init() {
+	Register("hazard", func(a ItemArgs) (GameObject, error) {
+		o := NewHazard(sha.IDHazard, a.X, a.Y, a.W, a.H, a.Props.GetColor("color", sha.Red50))
+		o.SetRotation(a.Rotation * DegToRad)
+		return &o, nil
+	})
+}
+
+// NewHazard constructor
+func NewHazard(id, x, y, w, h int, c color.RGBA) Hazard {
+	return Hazard{Object: NewObject(id, nil, x, y, ZTrigger, Vector{}, 0, 0, w, h, false, c)}
+}
+
+// OnEnter implements ContactListener, resets the player
+func (o *Hazard) OnEnter(e ContactEvent) {
+	if p, ok := e.Other.(*Player); ok {
+		p.reset()
+	}
+}
+
+// OnStay implements ContactListener
+func (o *Hazard) OnStay(e ContactEvent) {
+}
+
+// OnExit implements ContactListener
+func (o *Hazard) OnExit(e ContactEvent) {
+}
@@ -2,86 +2,183 @@ package com
 
 import "math"
 
-// Sides has a boolean for each side, to indicate collision on specific side('s)
-type Sides struct {
-	left, right, top, bottom bool
+// Contact is the result of a shape collision, Normal points from the target to the collider
+// (the direction to push the collider out) and Depth is the penetration along the normal
+type Contact struct {
+	Normal Vector
+	Depth  float64
 }
 
-// hitHV returs true if there is a horizontal and vertical hit
-func (s *Sides) hitHV() bool {
-	return (s.left || s.right) && (s.top || s.bottom)
+// Shaped is implemented by objects which can collide on convex shapes instead of their hit rect,
+// a shape is a convex polygon (or a line segment) in world coordinates. No shapes (nil) means
+// the hit rect is the exact shape
+type Shaped interface {
+	GetShapes() [][]Vector
 }
 
-// CheckHit checks for a hit between two objects and
-// optionally returns the hit side('s) from which we hit the targets
-func CheckHit(o *Object, target *Object, resolveSides bool, resolveHV bool) (bool, Sides) {
-	r, t := &o.rect, &target.rect
-	var sides Sides
-	if CheckOverlap(r, t) {
-		if resolveSides {
-			checkSides(&sides, r, t)
-			if resolveHV {
-				resolveHitHV(&sides, getIntersetRect(r, t))
-				//fmt.Printf("%s hits %s on sides %+v\n", con.ID[o.ID], con.ID[target.ID], sides)
+// shapes closer than contactSlop still touch, hit rects are integers so a pushed out collider can
+// end up a fraction of a pixel away, without slop a landed ship would lose contact every other tick
+const contactSlop = 1.0
+
+// CheckPolygons checks for a hit between two convex polygons with a separating axis test,
+// the contact is the smallest push which separates a from b
+func CheckPolygons(a, b []Vector) (bool, Contact) {
+	contact := Contact{Depth: math.Inf(1)}
+	for _, axis := range append(getAxes(a), getAxes(b)...) {
+		minA, maxA := project(a, axis)
+		minB, maxB := project(b, axis)
+		// push needed to move a out of b, along the axis or against it
+		// (a line segment has no width, so the overlap of the projections would always be 0)
+		along, against := maxB-minA, maxA-minB
+		overlap, normal := along, axis
+		if against < along {
+			overlap, normal = against, axis.scale(-1)
+		}
+		if overlap < -contactSlop {
+			return false, Contact{}
+		}
+		if overlap < contact.Depth {
+			contact = Contact{Normal: normal, Depth: math.Max(overlap, 0)}
+		}
+	}
+	if math.IsInf(contact.Depth, 1) {
+		return false, Contact{}
+	}
+	return true, contact
+}
+
+// SweepPolygons finds the time of impact of polygon a moving by d against polygon b, with a swept
+// separating axis test. t is the part of d (0 - 1) a moves before it touches b, the normal points
+// from b to a. Polygons which already overlap at the start are no hit, CheckPolygons resolves those
+func SweepPolygons(a []Vector, d Vector, b []Vector) (hit bool, t float64, normal Vector) {
+	enter, exit := math.Inf(-1), math.Inf(1)
+	for _, axis := range append(getAxes(a), getAxes(b)...) {
+		minA, maxA := project(a, axis)
+		minB, maxB := project(b, axis)
+		v := d.dot(axis)
+		if v == 0 {
+			// not moving on this axis, separated is never hit
+			if maxA < minB || minA > maxB {
+				return false, 0, Vector{}
 			}
+			continue
 		}
-		return true, sides
+		// times at which the projections start and stop to overlap
+		t0, t1 := (minB-maxA)/v, (maxB-minA)/v
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > enter {
+			enter = t0
+			normal = axis
+			if v > 0 {
+				normal = axis.scale(-1)
+			}
+		}
+		exit = math.Min(exit, t1)
+		if enter > exit {
+			return false, 0, Vector{}
+		}
+	}
+	if enter < 0 || enter > 1 {
+		return false, 0, Vector{}
 	}
-	return false, sides
+	return true, enter, normal
 }
 
-// CheckOverlap checks for a hit between two rects
-func CheckOverlap(r *Rect, t *Rect) bool {
-	if !(r.x > t.x+t.w || r.x+r.w < t.x || r.y > t.y+t.h || r.y+r.h < t.y) {
-		return true
+// SweepShapes checks the last move of o (its moved vector, which ends at its current hit shape)
+// against the shapes of a target, returns the earliest time of impact and its normal
+func SweepShapes(o *Object, target *Object, shapes [][]Vector) (bool, float64, Vector) {
+	bounds := o.sweptBounds()
+	if !CheckOverlap(&bounds, &target.rect) {
+		return false, 0, Vector{}
 	}
-	return false
+	start := make([]Vector, 0, 4)
+	for _, p := range o.getPolygon() {
+		start = append(start, p.sub(o.moved))
+	}
+	hit, toi, normal := false, math.Inf(1), Vector{}
+	for _, shape := range shapes {
+		if h, t, n := SweepPolygons(start, o.moved, shape); h && t < toi {
+			hit, toi, normal = true, t, n
+		}
+	}
+	return hit, toi, normal
+}
+
+// GetShapes returns the shapes of a target, or nil when it collides on its hit rect
+func GetShapes(target GameObject) [][]Vector {
+	if s, ok := target.(Shaped); ok {
+		return s.GetShapes()
+	}
+	return nil
 }
 
-// checkSides returns which side('s) we hit a target (assumes an overlap)
-func checkSides(sides *Sides, r *Rect, t *Rect) {
-	// dont remove equals signs, else e.g if the collider is bigger then the target
-	// a hit bottom could return only hit Left, right as true
-	// most likely rounding between position float and integers of the hit rect
-	aLeft, aRight := r.x, r.x+r.w
-	aTop, aBottom := r.y, r.y+r.h
-	bLeft, bRight := t.x, t.x+t.w
-	bTop, bBottom := t.y, t.y+t.h
-	if aRight >= bLeft && aLeft < bLeft {
-		sides.left = true
+// GetHitShapes returns the shapes of a target, or its hit rect as shape
+func GetHitShapes(target GameObject) [][]Vector {
+	if shapes := GetShapes(target); shapes != nil {
+		return shapes
 	}
-	if aLeft <= bRight && aRight > bRight {
-		sides.right = true
+	return [][]Vector{target.GetObject().rect.points()}
+}
+
+// CheckShapes checks for hits between the hit shape (or rect) of o and the shapes of a target,
+// returns a contact per shape that is hit
+func CheckShapes(o *Object, target *Object, shapes [][]Vector) []Contact {
+	// quick check on the bounding box first
+	if !CheckOverlap(&o.rect, &target.rect) {
+		return nil
 	}
-	if aBottom >= bTop && aTop < bTop {
-		sides.top = true
+	var contacts []Contact
+	r := o.getPolygon()
+	for _, shape := range shapes {
+		if hit, c := CheckPolygons(r, shape); hit {
+			contacts = append(contacts, c)
+		}
 	}
-	if aTop <= bBottom && aBottom > bBottom {
-		sides.bottom = true
+	return contacts
+}
+
+// getBounds returns the smallest Rect around a polygon
+func getBounds(poly []Vector) Rect {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range poly {
+		minX, minY = math.Min(minX, p.x), math.Min(minY, p.y)
+		maxX, maxY = math.Max(maxX, p.x), math.Max(maxY, p.y)
 	}
+	x, y := int(math.Floor(minX)), int(math.Floor(minY))
+	return Rect{x, y, int(math.Ceil(maxX)) - x, int(math.Ceil(maxY)) - y}
 }
 
-// resolveHitHV resolves collisions in case we have a horizontal and vertical collision
-// with the same object, based on the overlap dimensions it ignores the horizontal or vertical hit.
-// so the collider only react to the biggest hit
-func resolveHitHV(sides *Sides, ir *Rect) {
-	//fmt.Println("ResolveHitHV, intersect rect: ", ir)
-	if sides.hitHV() {
-		if ir.w > ir.h {
-			sides.left, sides.right = false, false
-		} else {
-			sides.top, sides.bottom = false, false
+// getAxes returns the unit normals of the edges of a polygon
+func getAxes(poly []Vector) []Vector {
+	var axes []Vector
+	for i := range poly {
+		edge := poly[(i+1)%len(poly)].sub(poly[i])
+		if n := edge.perp().normalize(); n != (Vector{}) {
+			axes = append(axes, n)
 		}
 	}
+	return axes
 }
 
-// getIntersetRect returns the intersection rect between two rectanges
-func getIntersetRect(r *Rect, t *Rect) *Rect {
-	x1 := int(math.Max(float64(r.x), float64(t.x)))
-	y1 := int(math.Max(float64(r.y), float64(t.y)))
-	x2 := int(math.Min(float64(r.x+r.w), float64(t.x+t.w)))
-	y2 := int(math.Min(float64(r.y+r.h), float64(t.y+t.h)))
-	return &Rect{x1, y1, x2 - x1, y2 - y1}
+// project returns the min and max of a polygon projected on an axis
+func project(poly []Vector, axis Vector) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, p := range poly {
+		d := p.dot(axis)
+		min = math.Min(min, d)
+		max = math.Max(max, d)
+	}
+	return min, max
+}
+
+// CheckOverlap checks for a hit between two rects
+func CheckOverlap(r *Rect, t *Rect) bool {
+	if !(r.x > t.x+t.w || r.x+r.w < t.x || r.y > t.y+t.h || r.y+r.h < t.y) {
+		return true
+	}
+	return false
 }
 
 // GetRotatedPoint transforms points
@@ -0,0 +1,49 @@
+package com
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	sha "moonlander/src/shared"
+)
+
+// moveObject returns a 20x20 object at x, y after one tick moving by move
+func moveObject(x, y int, move Vector) *Object {
+	o := NewObject(0, nil, x, y, 0, move.scale(1/sha.DT), 0, 0, 20, 20, true, color.RGBA{})
+	o.Update(nil)
+	return &o
+}
+
+func TestSweepThinWall(t *testing.T) {
+	// 4 pixels wide, the ship moves 60 pixels in one tick, from before to past the wall
+	wall := NewWall(0, 100, 0, 4, 100, color.RGBA{})
+	o := moveObject(50, 40, Vector{60, 0})
+	if CheckOverlap(o.GetRect(), wall.GetRect()) {
+		t.Fatal("ship overlaps the wall after the move, the sweep isn't needed")
+	}
+
+	hit, toi, normal := SweepShapes(o, &wall.Object, GetHitShapes(&wall))
+	if !hit {
+		t.Fatal("no hit, the ship passed through the wall")
+	}
+	if toi < 0 || toi >= 1 {
+		t.Errorf("time of impact %v, want in [0, 1)", toi)
+	}
+	// the right side of the ship (70) touches the wall (100) after half of the move
+	if math.Abs(toi-0.5) > 1e-9 {
+		t.Errorf("time of impact %v, want 0.5", toi)
+	}
+	if want := (Vector{-1, 0}); normal != want {
+		t.Errorf("normal %v, want %v", normal, want)
+	}
+}
+
+func TestSweepMiss(t *testing.T) {
+	// the ship passes below the wall
+	wall := NewWall(0, 100, 0, 4, 100, color.RGBA{})
+	o := moveObject(50, 200, Vector{60, 0})
+	if hit, _, _ := SweepShapes(o, &wall.Object, GetHitShapes(&wall)); hit {
+		t.Error("hit, want a miss")
+	}
+}
@@ -0,0 +1,69 @@
+package com
+
+import (
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Controls are the controls of the player in one tick
+type Controls struct {
+	up, down, left, right, rr, rl, reset bool
+}
+
+// bits of the controls, in the order they are packed
+const (
+	ctrlUp byte = 1 << iota
+	ctrlDown
+	ctrlLeft
+	ctrlRight
+	ctrlRR
+	ctrlRL
+	ctrlReset
+)
+
+// Bits packs the controls in a byte, as stored in replays
+func (c Controls) Bits() byte {
+	var b byte
+	for _, f := range []struct {
+		on  bool
+		bit byte
+	}{{c.up, ctrlUp}, {c.down, ctrlDown}, {c.left, ctrlLeft}, {c.right, ctrlRight}, {c.rr, ctrlRR}, {c.rl, ctrlRL}, {c.reset, ctrlReset}} {
+		if f.on {
+			b |= f.bit
+		}
+	}
+	return b
+}
+
+// ControlsFromBits unpacks controls packed by Bits
+func ControlsFromBits(b byte) Controls {
+	return Controls{
+		up:    b&ctrlUp != 0,
+		down:  b&ctrlDown != 0,
+		left:  b&ctrlLeft != 0,
+		right: b&ctrlRight != 0,
+		rr:    b&ctrlRR != 0,
+		rl:    b&ctrlRL != 0,
+		reset: b&ctrlReset != 0,
+	}
+}
+
+// Input gives the controls of the player, Read is called once every tick
+type Input interface {
+	Read() Controls
+}
+
+// KeyboardInput reads the controls from the keyboard
+type KeyboardInput struct{}
+
+// Read implements Input
+func (KeyboardInput) Read() Controls {
+	return Controls{
+		up:    ebiten.IsKeyPressed(ebiten.KeyUp),
+		down:  ebiten.IsKeyPressed(ebiten.KeyDown),
+		left:  ebiten.IsKeyPressed(ebiten.KeyLeft),
+		right: ebiten.IsKeyPressed(ebiten.KeyRight),
+		rl:    ebiten.IsKeyPressed(ebiten.KeyZ),
+		rr:    ebiten.IsKeyPressed(ebiten.KeyX),
+		reset: ebiten.IsKeyPressed(ebiten.KeyBackspace),
+	}
+}
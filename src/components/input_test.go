@@ -0,0 +1,16 @@
+package com
+
+import "testing"
+
+func TestControlsBits(t *testing.T) {
+	// every combination of the 7 controls
+	for b := 0; b < 1<<7; b++ {
+		c := ControlsFromBits(byte(b))
+		if got := c.Bits(); got != byte(b) {
+			t.Errorf("ControlsFromBits(%#x).Bits() = %#x", b, got)
+		}
+		if got := ControlsFromBits(c.Bits()); got != c {
+			t.Errorf("ControlsFromBits(%+v.Bits()) = %+v", c, got)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package com
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layer is a set of collision layers, an object is on its layer and collides with the layers in its mask
+type Layer uint32
+
+// Collision layers
+const (
+	LayerTerrain Layer = 1 << iota
+	LayerShip
+	LayerTrigger
+	LayerProjectile
+	LayerDebris
+
+	LayerNone Layer = 0
+	LayerAll  Layer = LayerTerrain | LayerShip | LayerTrigger | LayerProjectile | LayerDebris
+)
+
+// LayerNames are the names of the layers, as used in the layer and mask properties in Tiled
+var LayerNames = map[string]Layer{
+	"terrain":    LayerTerrain,
+	"ship":       LayerShip,
+	"trigger":    LayerTrigger,
+	"projectile": LayerProjectile,
+	"debris":     LayerDebris,
+	"none":       LayerNone,
+	"all":        LayerAll,
+}
+
+// ParseLayers parses a comma separated list of layer names, like "terrain,debris"
+func ParseLayers(s string) (Layer, error) {
+	var l Layer
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		n, ok := LayerNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown collision layer %q", name)
+		}
+		l |= n
+	}
+	return l, nil
+}
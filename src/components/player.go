@@ -2,7 +2,6 @@ package com
 
 import (
 	"image/color"
-	"log"
 	"math"
 
 	ass "moonlander/assets"
@@ -14,65 +13,66 @@ import (
 
 // Player is a controllable object
 type Player struct {
-	animL, animR, animU, animD    Anim
+	thrusters
 	imgW, imgH, imgHW, imgHH      float64
 	weight, thrust, retro, zSpeed float64
-	hx, hy, hw, hh, hdiff         int
+	hw, hh                        int
 	grounded                      bool
-	collideObject                 *Object
+	lp                            *sha.LevelProperties
+	input                         Input
 	Object
 	Controls
 }
 
-// Controls stuff
-type Controls struct {
-	up, down, left, right, rr, rl bool
+// surface normals with an upwards part bigger than landNormal (cos 30 degrees) can be landed on
+const landNormal = 0.87
+
+func init() {
+	Register("player", func(a ItemArgs) (GameObject, error) {
+		p := a.Props
+		o, err := NewPlayer(sha.IDPlayer, a.X, a.Y, ZShip, Vector{},
+			p.GetInt("hitX", 8), p.GetInt("hitY", 8), p.GetInt("hitW", 30), p.GetInt("hitH", 48),
+			p.GetColor("color", sha.Red50), a.Level)
+		if err != nil {
+			return nil, err
+		}
+		return &o, nil
+	})
 }
 
-// NewPlayer constructor
-func NewPlayer(id int, x, y, z int, v Vector, hx, hy, hw, hh int, c color.RGBA) Player {
+// NewPlayer constructor, lp are the properties of the level the player flies in
+func NewPlayer(id int, x, y, z int, v Vector, hx, hy, hw, hh int, c color.RGBA, lp *sha.LevelProperties) (Player, error) {
 	img, _, err := ebitenutil.NewImageFromFile("assets/spaceship.png", ebiten.FilterDefault)
 	if err != nil {
-		log.Fatal(err)
+		return Player{}, err
 	}
 	wImg, hImg := img.Size()
 	p := Player{
-		Object:   NewObject(id, img, x, y, z, v, hx, hy, hw, hh, true, c),
-		Controls: Controls{false, false, false, false, false, false},
-		weight:   1,
-		thrust:   0.06,
-		retro:    0.03,
-		zSpeed:   1.2,
-		imgW:     float64(wImg),
-		imgH:     float64(hImg),
-		imgHW:    float64(wImg / 2),
-		imgHH:    float64(hImg / 2),
-		// keep original hit rect values, to calc rotating hit rect
-		hx: hx, hy: hy, hw: hw, hh: hh,
-		hdiff: (hw - hh) / 2,
+		Object: NewObject(id, img, x, y, z, v, hx, hy, hw, hh, true, c),
+		input:  KeyboardInput{},
+		weight: 1,
+		// pixels per second per second, and degrees per second
+		thrust: 216,
+		retro:  108,
+		zSpeed: 72,
+		imgW:   float64(wImg),
+		imgH:   float64(hImg),
+		imgHW:  float64(wImg / 2),
+		imgHH:  float64(hImg / 2),
+		// keep original hit box size, to calc rotating hit shape
+		hw: hw, hh: hh,
+		lp: lp,
 	}
-	p.animU = NewAnimFromByte(ass.Up, 0, 0, 0, NewVector(0, 0), NewFrame(0, 0, 20, 48, 3, 5))
-	p.animD = NewAnimFromByte(ass.Down, 0, 0, 0, NewVector(0, 0), NewFrame(0, 0, 10, 32, 3, 5))
-	p.animL = NewAnimFromByte(ass.Left, 0, 0, 0, NewVector(0, 0), NewFrame(0, 0, 32, 10, 3, 5))
-	p.animR = NewAnimFromByte(ass.Right, 0, 0, 0, NewVector(0, 0), NewFrame(0, 0, 32, 10, 3, 5))
+	p.thrusters = newThrusters()
 	p.debug = false
-	return p
+	p.SetLayers(LayerShip, LayerTerrain|LayerTrigger|LayerDebris)
+	p.rotateShape(p.X+p.imgHW, p.Y+p.imgHH, 0, hw, hh)
+	return p, nil
 }
 
 // Draw Player
 func (o *Player) Draw(screen *ebiten.Image) error {
-	if o.Controls.left || o.Controls.rl {
-		o.animL.Draw(screen)
-	}
-	if o.Controls.right || o.Controls.rr {
-		o.animR.Draw(screen)
-	}
-	if o.Controls.up {
-		o.animU.Draw(screen)
-	}
-	if o.Controls.down {
-		o.animD.Draw(screen)
-	}
+	o.thrusters.draw(screen, o.Controls, 1)
 	if o.Img != nil {
 		op := &ebiten.DrawImageOptions{}
 		op.GeoM.Translate(-o.imgHW, -o.imgHH)
@@ -81,12 +81,12 @@ func (o *Player) Draw(screen *ebiten.Image) error {
 		screen.DrawImage(o.Img, op)
 	}
 	if o.debug {
-		// draw hit shape (need to recreate image, because it changes shape)
+		// draw hit shape, rotated like the image
 		if o.rectImg != nil {
-			o.rectImg, _ = ebiten.NewImage(o.rect.w, o.rect.h, ebiten.FilterNearest)
-			o.rectImg.Fill(sha.Cyan25)
 			op := &ebiten.DrawImageOptions{}
-			op.GeoM.Translate(float64(o.rect.x), float64(o.rect.y))
+			op.GeoM.Translate(float64(o.rx)-o.imgHW, float64(o.ry)-o.imgHH)
+			op.GeoM.Rotate(o.R)
+			op.GeoM.Translate(o.X+o.imgHW, o.Y+o.imgHH)
 			screen.DrawImage(o.rectImg, op)
 		}
 	}
@@ -97,37 +97,23 @@ func (o *Player) Draw(screen *ebiten.Image) error {
 
 // Update Player
 func (o *Player) Update(screen *ebiten.Image) error {
-	// keep track of all keys pressed
-	o.Controls = Controls{false, false, false, false, false, false}
-	if ebiten.IsKeyPressed(ebiten.KeyUp) {
-		o.Controls.up = true
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyDown) && !o.grounded {
-		o.Controls.down = true
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) && !o.grounded {
-		o.Controls.left = true
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyRight) && !o.grounded {
-		o.Controls.right = true
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyZ) && !o.grounded {
-		o.Controls.rl = true
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyX) && !o.grounded {
-		o.Controls.rr = true
+	// controls of this tick, only the main thruster works when grounded
+	o.Controls = o.input.Read()
+	if o.grounded {
+		o.Controls.down, o.Controls.left, o.Controls.right = false, false, false
+		o.Controls.rl, o.Controls.rr = false, false
 	}
 	// reset Player
-	if ebiten.IsKeyPressed(ebiten.KeyBackspace) {
+	if o.Controls.reset {
 		o.reset()
 	}
 
 	// rotation
 	if o.Controls.rl {
-		o.R -= o.zSpeed * DegToRad
+		o.R -= o.zSpeed * sha.DT * DegToRad
 	}
 	if o.Controls.rr {
-		o.R += o.zSpeed * DegToRad
+		o.R += o.zSpeed * sha.DT * DegToRad
 	}
 	// convert radials always to be always positive between 0 - (2*Pi)
 	if o.R < 0 {
@@ -140,39 +126,36 @@ func (o *Player) Update(screen *ebiten.Image) error {
 	zx := math.Sin(o.R)
 	zy := math.Cos(o.R)
 
-	//change hit shape, when rotating (morph between horizontal and vertical shape)
-	hMP := math.Pow(zx, 2) //[0 ..1] horizontal
-	vMP := math.Pow(zy, 2) //[0 ..1] vertical
-	o.rect.w = int(float64(o.hh)*hMP + float64(o.hw)*vMP)
-	o.rect.h = int(float64(o.hw)*hMP + float64(o.hh)*vMP)
-	o.rx = o.hx + int(float64(o.hdiff)*hMP)
-	o.ry = o.hy + int(float64(o.hdiff)*hMP)*-1
-
 	// add velocity when pressing certan keys
+	thrust, retro := o.thrust*sha.DT, o.retro*sha.DT
 	if o.Controls.up {
-		o.Vector.x -= o.thrust * zx * -1
-		o.Vector.y -= o.thrust * zy
+		o.Vector.x -= thrust * zx * -1
+		o.Vector.y -= thrust * zy
 	}
 	if o.Controls.down {
-		o.Vector.x += o.retro * zx * -1
-		o.Vector.y += o.retro * zy
+		o.Vector.x += retro * zx * -1
+		o.Vector.y += retro * zy
 	}
 	if o.Controls.right {
-		o.Vector.x += o.retro * zy
-		o.Vector.y += o.retro * zx
+		o.Vector.x += retro * zy
+		o.Vector.y += retro * zx
 	}
 	if o.Controls.left {
-		o.Vector.x -= o.retro * zy
-		o.Vector.y -= o.retro * zx
+		o.Vector.x -= retro * zy
+		o.Vector.y -= retro * zx
 	}
 
+	// the level gravity is in pixels per tick per tick at tuneTPS, rotations in radials per tick
+	gravity := o.lp.Gravity * tuneTPS * tuneTPS
+	ticks := tuneTPS * sha.DT
+
 	if o.grounded {
 		// when grounded, remove horizontal velocity and set ship facing up
-		o.Vector.x *= 0.96
+		o.Vector.x *= damp(0.96)
 		if o.R < PI {
-			o.R -= 0.1 * (o.R / PI)
+			o.R -= 0.1 * ticks * (o.R / PI)
 		} else {
-			o.R += 0.1 * (DPI - o.R) / PI
+			o.R += 0.1 * ticks * (DPI - o.R) / PI
 		}
 		// snap last part, because we ease out in rotation
 		if o.R < 0.01 || o.R > PI*2-0.01 {
@@ -185,129 +168,149 @@ func (o *Player) Update(screen *ebiten.Image) error {
 		if o.R > 0.01 && o.R < PI {
 			if o.R < HPI {
 				// go nose up
-				o.R -= (sha.LP.Gravity / 6) * ((HPI - o.R) / HPI)
+				o.R -= (o.lp.Gravity / 6) * ticks * ((HPI - o.R) / HPI)
 			} else {
 				// go nose down
-				o.R += (sha.LP.Gravity / 4) * ((HPI - o.R) / HPI) * -1
+				o.R += (o.lp.Gravity / 4) * ticks * ((HPI - o.R) / HPI) * -1
 			}
 		}
 		if o.R < DPI-0.01 && o.R > PI {
 			if o.R < (PI * 1.5) {
 				// go nose down
-				o.R -= (sha.LP.Gravity / 4) * ((PI/2*3 - o.R) / HPI)
+				o.R -= (o.lp.Gravity / 4) * ticks * ((PI/2*3 - o.R) / HPI)
 			} else {
 				// go nose up
-				o.R += (sha.LP.Gravity / 6) * ((PI/2*3 - o.R) / HPI) * -1
+				o.R += (o.lp.Gravity / 6) * ticks * ((PI/2*3 - o.R) / HPI) * -1
 			}
 		}
 
 		//add 'atmosphere' friction
-		o.Vector.x *= sha.LP.Friction * o.weight
-		o.Vector.y *= sha.LP.Friction * o.weight
+		o.Vector = o.Vector.scale(damp(o.lp.Friction * o.weight))
 
 		// add gravity
-		o.Vector.y += sha.LP.Gravity * o.weight
+		o.Vector.y += gravity * o.weight * sha.DT
 	}
 
 	// update player position
-	o.X += o.Vector.x
-	o.Y += o.Vector.y
+	o.moved = o.Vector.scale(sha.DT)
+	o.X += o.moved.x
+	o.Y += o.moved.y
 
-	// update hit rect
-	o.rect.setXY(int(o.X)+o.rx, int(o.Y)+o.ry)
+	// update hit shape, the hit box rotates with the image around its center
+	o.rotateShape(o.X+o.imgHW, o.Y+o.imgHH, o.R, o.hw, o.hh)
 
 	// also update anim location + rotation, based on player rotation + location
 	// do this after player postion is updated
-	cx, cy := o.X+o.imgHW, o.Y+o.imgHH
-	if o.Controls.up {
-		o.animU.X, o.animU.Y = GetRotatedPoint(cx, cy, 0, +(o.imgHH + 16), o.R)
-		o.animU.R = o.R
-		o.animU.Update(screen)
-	}
-	if o.Controls.down {
-		o.animD.R = o.R
-		o.animD.X, o.animD.Y = GetRotatedPoint(cx, cy, 0, -(o.imgHH + 16), o.R)
-		o.animD.Update(screen)
-	}
-	if o.Controls.right || o.Controls.rr {
-		o.animR.R = o.R
-		o.animR.X, o.animR.Y = GetRotatedPoint(cx, cy, -(o.imgHW + 8), -10, o.R)
-		o.animR.Update(screen)
-	}
-	if o.Controls.left || o.Controls.rl {
-		o.animL.R = o.R
-		o.animL.X, o.animL.Y = GetRotatedPoint(cx, cy, +(o.imgHW + 8), -10, o.R)
-		o.animL.Update(screen)
-	}
+	o.thrusters.update(screen, o.Controls, o.X+o.imgHW, o.Y+o.imgHH, o.imgHW, o.imgHH, o.R)
 	return nil
 }
 
 // Collide implements interface, handles collission with ojects
+// solid objects push the player out along the contact normal, all contacts are recorded for the contact events
 func (o *Player) Collide(hitAbles []GameObject) error {
 	o.grounded = false
 
+	// stop at the first solid object in the way, a fast ship would otherwise pass through thin walls
+	if t, n, ok := o.sweep(hitAbles); ok {
+		o.resolveContact(t, Contact{Normal: n})
+	}
 	for _, h := range hitAbles {
 		t := h.GetObject()
-		if &o.rect != &t.rect {
-			hit, sides := CheckHit(o.GetObject(), t, true, true)
-			if hit {
-				//fmt.Printf("%s hits %s on sides %+v\n", sha.ID[o.ID], sha.ID[t.ID], sides)
-				o.addHit(t)
-
-				// player hits somehting solid
-				if t.solid {
-					if sides.left {
-						o.X = float64(t.rect.x-o.rect.w-o.rx) - 1
-						o.Vector.x = 0
-					}
-					if sides.right {
-						o.X = float64(t.rect.x+t.rect.w-o.rx) + 1
-						o.Vector.x = 0
-					}
-					if sides.top {
-						// if we hit a wall, set player to grounded and set on top block, without offset
-						if t.ID == sha.IDWall && !o.grounded {
-							o.Y = float64(t.rect.y - o.rect.h - o.ry)
-							o.grounded = true
-						} else {
-							o.Y = float64(t.rect.y-o.rect.h-o.ry) - 0
-							o.Vector.y = 0
-						}
-					}
-					if sides.bottom {
-						o.Y = float64(t.rect.y+t.rect.h-o.ry) + 1
-						o.Vector.y = 0
-					}
-				} else {
-					if t.ID == sha.IDCheckpoint {
-						h.SetHit(o)
-					} else if t.ID == sha.IDFinish {
-						h.SetHit(o)
-					}
-				}
-
+		if t == o.GetObject() {
+			continue
+		}
+		for _, c := range CheckShapes(o.GetObject(), t, GetHitShapes(h)) {
+			o.addContact(h, c)
+			if !t.solid {
+				break
 			}
+			o.resolveContact(t, c)
 		}
 	}
 	return nil
 }
 
+// resolveContact pushes the player out of a shape and removes the velocity into it,
+// the player lands on the terrain layer where it is flat enough
+func (o *Player) resolveContact(t *Object, c Contact) {
+	o.pushOut(c)
+	if vn := o.Vector.dot(c.Normal); vn < 0 {
+		o.Vector = o.Vector.sub(c.Normal.scale(vn))
+	}
+	if c.Normal.y < -landNormal && t.layer&LayerTerrain != 0 {
+		o.grounded = true
+	}
+}
+
+// GhostFrame returns the position, rotation and controls of this tick, for a ghost trace
+func (o *Player) GhostFrame() GhostFrame {
+	return GhostFrame{X: o.X, Y: o.Y, R: o.R, Controls: o.Controls.Bits()}
+}
+
+// SetInput sets where the controls come from, like the keyboard or a replay
+func (o *Player) SetInput(in Input) {
+	o.input = in
+}
+
 func (o *Player) reset() {
-	o.X = float64(sha.LP.PlayerStartX)
-	o.Y = float64(sha.LP.PlayerStartY)
+	o.X = float64(o.lp.PlayerStartX)
+	o.Y = float64(o.lp.PlayerStartY)
 	o.R = 0
 	o.Vector.x = 0
 	o.Vector.y = 0
 }
 
-func (o *Player) addHit(obj *Object) {
-	obj.Hit = true
-	o.collideObject = obj
+// thrusters are the thrust animations of a ship
+type thrusters struct {
+	animL, animR, animU, animD Anim
 }
 
-func (o *Player) removeHit() {
-	if o.collideObject != nil {
-		o.collideObject.Hit = false
-		o.collideObject = nil
+func newThrusters() thrusters {
+	return thrusters{
+		animU: NewAnimFromByte(ass.Up, 0, 0, ZParticles, NewVector(0, 0), NewFrame(0, 0, 20, 48, 3, 5)),
+		animD: NewAnimFromByte(ass.Down, 0, 0, ZParticles, NewVector(0, 0), NewFrame(0, 0, 10, 32, 3, 5)),
+		animL: NewAnimFromByte(ass.Left, 0, 0, ZParticles, NewVector(0, 0), NewFrame(0, 0, 32, 10, 3, 5)),
+		animR: NewAnimFromByte(ass.Right, 0, 0, ZParticles, NewVector(0, 0), NewFrame(0, 0, 32, 10, 3, 5)),
+	}
+}
+
+// update places and animates the thrusters which fire, around the ship center cx, cy
+// of a ship with half size hw, hh rotated by r
+func (t *thrusters) update(screen *ebiten.Image, c Controls, cx, cy, hw, hh, r float64) {
+	if c.up {
+		t.animU.X, t.animU.Y = GetRotatedPoint(cx, cy, 0, +(hh + 16), r)
+		t.animU.R = r
+		t.animU.Update(screen)
+	}
+	if c.down {
+		t.animD.R = r
+		t.animD.X, t.animD.Y = GetRotatedPoint(cx, cy, 0, -(hh + 16), r)
+		t.animD.Update(screen)
+	}
+	if c.right || c.rr {
+		t.animR.R = r
+		t.animR.X, t.animR.Y = GetRotatedPoint(cx, cy, -(hw + 8), -10, r)
+		t.animR.Update(screen)
+	}
+	if c.left || c.rl {
+		t.animL.R = r
+		t.animL.X, t.animL.Y = GetRotatedPoint(cx, cy, +(hw + 8), -10, r)
+		t.animL.Update(screen)
+	}
+}
+
+// draw draws the thrusters which fire, with an alpha (0 - 1)
+func (t *thrusters) draw(screen *ebiten.Image, c Controls, alpha float64) {
+	if c.left || c.rl {
+		t.animL.drawAlpha(screen, alpha)
+	}
+	if c.right || c.rr {
+		t.animR.drawAlpha(screen, alpha)
+	}
+	if c.up {
+		t.animU.drawAlpha(screen, alpha)
+	}
+	if c.down {
+		t.animD.drawAlpha(screen, alpha)
 	}
 }
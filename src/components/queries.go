@@ -0,0 +1,200 @@
+package com
+
+import (
+	"math"
+)
+
+// RayHit is the first object a ray hits, the point and the surface normal where it is hit,
+// and the distance from the origin of the ray
+type RayHit struct {
+	Object   GameObject
+	Point    Vector
+	Normal   Vector
+	Distance float64
+}
+
+// Raycast returns the first object on a layer in mask which is hit by a ray from origin in direction dir,
+// no further than maxDist. The cells are walked along the ray, so long rays only test what is near the ray
+func (s *SpatialHash) Raycast(origin, dir Vector, maxDist float64, mask Layer) (RayHit, bool) {
+	dir = dir.normalize()
+	if dir == (Vector{}) || maxDist <= 0 {
+		return RayHit{}, false
+	}
+	// clip the ray to the grid
+	t0, t1, ok := clipRay(origin, dir, 0, 0, float64(s.cols*s.cell), float64(s.rows*s.cell))
+	if !ok || t0 > maxDist {
+		return RayHit{}, false
+	}
+	t1 = math.Min(t1, maxDist)
+
+	// walk the cells along the ray, from the cell where it enters the grid
+	start := origin.add(dir.scale(t0))
+	cx := s.clamp(int(start.x)/s.cell, s.cols)
+	cy := s.clamp(int(start.y)/s.cell, s.rows)
+	stepX, nextX, deltaX := rayStep(start.x, dir.x, cx, s.cell)
+	stepY, nextY, deltaY := rayStep(start.y, dir.y, cy, s.cell)
+
+	s.stamp++
+	best := RayHit{Distance: math.Inf(1)}
+	for {
+		for _, c := range s.cells[cx+cy*s.cols] {
+			e := s.entries[c]
+			if e.stamp == s.stamp || c.GetObject().layer&mask == 0 {
+				continue
+			}
+			e.stamp = s.stamp
+			for _, shape := range GetHitShapes(c) {
+				if d, n, hit := rayPolygon(origin, dir, shape); hit && d <= maxDist && d < best.Distance {
+					best = RayHit{Object: c, Point: origin.add(dir.scale(d)), Normal: n, Distance: d}
+				}
+			}
+		}
+		// the distance along the ray to where it leaves this cell
+		exit := t0 + math.Min(nextX, nextY)
+		if best.Distance <= exit || exit >= t1 {
+			break
+		}
+		if nextX < nextY {
+			cx += stepX
+			nextX += deltaX
+		} else {
+			cy += stepY
+			nextY += deltaY
+		}
+		if cx < 0 || cx >= s.cols || cy < 0 || cy >= s.rows {
+			break
+		}
+	}
+	return best, best.Object != nil
+}
+
+// QueryRect returns the objects on a layer in mask which overlap a rect (touching counts, like in collisions)
+func (s *SpatialHash) QueryRect(x, y, w, h int, mask Layer) []GameObject {
+	r := NewRect(x, y, w, h)
+	area := r.points()
+	return s.query(r, mask, func(shape []Vector) bool {
+		hit, _ := CheckPolygons(area, shape)
+		return hit
+	})
+}
+
+// QueryCircle returns the objects on a layer in mask which overlap a circle
+func (s *SpatialHash) QueryCircle(center Vector, radius float64, mask Layer) []GameObject {
+	d := int(math.Ceil(radius))
+	r := NewRect(int(center.x)-d, int(center.y)-d, 2*d, 2*d)
+	return s.query(r, mask, func(shape []Vector) bool {
+		return circlePolygon(center, radius, shape)
+	})
+}
+
+// query returns the objects on a layer in mask in the cells of r, for which hit is true on one of its shapes
+func (s *SpatialHash) query(r Rect, mask Layer, hit func(shape []Vector) bool) []GameObject {
+	s.stamp++
+	var found []GameObject
+	x0, y0, x1, y1 := s.cellRange(&r, 0)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			for _, c := range s.cells[x+y*s.cols] {
+				e := s.entries[c]
+				if e.stamp == s.stamp || c.GetObject().layer&mask == 0 {
+					continue
+				}
+				e.stamp = s.stamp
+				if !CheckOverlap(&r, c.GetObject().GetRect()) {
+					continue
+				}
+				for _, shape := range GetHitShapes(c) {
+					if hit(shape) {
+						found = append(found, c)
+						break
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+// clipRay returns the distances along the ray where it enters and leaves a rect
+func clipRay(origin, dir Vector, minX, minY, maxX, maxY float64) (float64, float64, bool) {
+	t0, t1 := 0.0, math.Inf(1)
+	for _, a := range [][4]float64{{origin.x, dir.x, minX, maxX}, {origin.y, dir.y, minY, maxY}} {
+		o, d, min, max := a[0], a[1], a[2], a[3]
+		if d == 0 {
+			if o < min || o > max {
+				return 0, 0, false
+			}
+			continue
+		}
+		ta, tb := (min-o)/d, (max-o)/d
+		if ta > tb {
+			ta, tb = tb, ta
+		}
+		t0, t1 = math.Max(t0, ta), math.Min(t1, tb)
+	}
+	return t0, t1, t0 <= t1
+}
+
+// rayStep returns the cell step, the distance to the first cell border and the distance between borders,
+// along one axis of a ray which starts at p in cell c
+func rayStep(p, d float64, c, cell int) (int, float64, float64) {
+	if d == 0 {
+		return 0, math.Inf(1), math.Inf(1)
+	}
+	delta := float64(cell) / math.Abs(d)
+	if d > 0 {
+		return 1, (float64((c+1)*cell) - p) / d, delta
+	}
+	return -1, (float64(c*cell) - p) / d, delta
+}
+
+// rayPolygon returns the distance to the first edge of a polygon (or line segment) a ray hits,
+// and the normal of that edge, facing the ray
+func rayPolygon(origin, dir Vector, poly []Vector) (float64, Vector, bool) {
+	best, normal, hit := math.Inf(1), Vector{}, false
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		edge := b.sub(a)
+		// solve origin + dir*t = a + edge*u
+		den := dir.x*edge.y - dir.y*edge.x
+		if den == 0 {
+			continue
+		}
+		ao := a.sub(origin)
+		t := (ao.x*edge.y - ao.y*edge.x) / den
+		u := (ao.x*dir.y - ao.y*dir.x) / den
+		if t < 0 || u < 0 || u > 1 || t >= best {
+			continue
+		}
+		n := edge.perp().normalize()
+		if n.dot(dir) > 0 {
+			n = n.scale(-1)
+		}
+		best, normal, hit = t, n, true
+	}
+	return best, normal, hit
+}
+
+// circlePolygon checks if a circle overlaps a convex polygon (or line segment)
+func circlePolygon(center Vector, radius float64, poly []Vector) bool {
+	// the center is inside when it is on the same side of every edge
+	left, right := 0, 0
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		edge := b.sub(a)
+		// closest point on the edge
+		u := 0.0
+		if l := edge.dot(edge); l > 0 {
+			u = math.Max(0, math.Min(1, center.sub(a).dot(edge)/l))
+		}
+		if center.sub(a.add(edge.scale(u))).length() <= radius {
+			return true
+		}
+		if edge.perp().dot(center.sub(a)) < 0 {
+			left++
+		} else {
+			right++
+		}
+	}
+	return len(poly) > 2 && (left == 0 || right == 0)
+}
@@ -0,0 +1,49 @@
+package com
+
+import (
+	"errors"
+	"fmt"
+
+	sha "moonlander/src/shared"
+)
+
+// ItemArgs are the values of a level item (Tiled object), passed to a Constructor
+type ItemArgs struct {
+	ID         int
+	Name       string
+	X, Y, W, H int
+	Rotation   float64
+	Props      sha.Props
+	// Points of a polygon or polyline (in world coordinates), Closed for a polygon
+	Points []Vector
+	Closed bool
+	// Level are the properties of the level (world) the item is in
+	Level *sha.LevelProperties
+}
+
+// Constructor creates a GameObject from a level item
+type Constructor func(a ItemArgs) (GameObject, error)
+
+// ErrUnknownType is returned by Construct when no constructor is registered for a type
+var ErrUnknownType = errors.New("unknown type")
+
+// constructors by Tiled type name
+var constructors = map[string]Constructor{}
+
+// Register adds a constructor for a Tiled type name, components register themselves in init()
+func Register(typ string, c Constructor) {
+	if _, ok := constructors[typ]; ok {
+		panic(fmt.Sprintf("com: constructor for type %q registered twice", typ))
+	}
+	constructors[typ] = c
+}
+
+// Construct creates a GameObject for a Tiled type name,
+// returns ErrUnknownType when no constructor is registered for the type
+func Construct(typ string, a ItemArgs) (GameObject, error) {
+	c, ok := constructors[typ]
+	if !ok {
+		return nil, ErrUnknownType
+	}
+	return c(a)
+}
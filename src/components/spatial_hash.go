@@ -0,0 +1,161 @@
+package com
+
+import (
+	"sort"
+)
+
+// SpatialHash is a uniform grid broadphase for hit detection,
+// static objects are added once, moving objects are moved to their new cells every tick
+type SpatialHash struct {
+	cell       int
+	cols, rows int
+	cells      [][]GameObject
+	entries    map[GameObject]*hashEntry
+	moving     []GameObject
+	stamp      int
+	added      int
+}
+
+// the cells an object is in, and its order, so candidates keep the order they were added in
+type hashEntry struct {
+	index          int
+	x0, y0, x1, y1 int
+	stamp          int
+}
+
+// NewSpatialHash constructor, covers a level of width x height in square cells of size cell
+// objects outside the level are kept in the border cells
+func NewSpatialHash(width, height, cell int) *SpatialHash {
+	cols := maxInt((width+cell-1)/cell, 1)
+	rows := maxInt((height+cell-1)/cell, 1)
+	return &SpatialHash{
+		cell:    cell,
+		cols:    cols,
+		rows:    rows,
+		cells:   make([][]GameObject, cols*rows),
+		entries: make(map[GameObject]*hashEntry),
+	}
+}
+
+// Add puts an object in the cells its hit rect covers, moving objects are updated by Update
+func (s *SpatialHash) Add(o GameObject, moving bool) {
+	if _, ok := s.entries[o]; ok {
+		return
+	}
+	s.added++
+	e := &hashEntry{index: s.added}
+	e.x0, e.y0, e.x1, e.y1 = s.cellRange(o.GetObject().GetRect(), 0)
+	s.entries[o] = e
+	s.insert(o, e)
+	if moving {
+		s.moving = append(s.moving, o)
+	}
+}
+
+// Remove takes an object out of the hash
+func (s *SpatialHash) Remove(o GameObject) {
+	e, ok := s.entries[o]
+	if !ok {
+		return
+	}
+	s.remove(o, e)
+	delete(s.entries, o)
+	for i, m := range s.moving {
+		if m == o {
+			s.moving = append(s.moving[:i], s.moving[i+1:]...)
+			break
+		}
+	}
+}
+
+// Update moves the moving objects to the cells of their current hit rect
+func (s *SpatialHash) Update() {
+	for _, o := range s.moving {
+		e := s.entries[o]
+		x0, y0, x1, y1 := s.cellRange(o.GetObject().GetRect(), 0)
+		if x0 == e.x0 && y0 == e.y0 && x1 == e.x1 && y1 == e.y1 {
+			continue
+		}
+		s.remove(o, e)
+		e.x0, e.y0, e.x1, e.y1 = x0, y0, x1, y1
+		s.insert(o, e)
+	}
+}
+
+// Query returns the objects in the cells around an object (without the object itself) which are on a layer
+// in its mask, in the order they were added, so collisions resolve the same as a full list would
+func (s *SpatialHash) Query(o GameObject) []GameObject {
+	s.stamp++
+	var found []GameObject
+	// the whole last move of o, with one pixel margin, objects touching it are candidates as well
+	r := o.GetObject().sweptBounds()
+	x0, y0, x1, y1 := s.cellRange(&r, 1)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			for _, c := range s.cells[x+y*s.cols] {
+				e := s.entries[c]
+				if c == o || e.stamp == s.stamp {
+					continue
+				}
+				e.stamp = s.stamp
+				if o.GetObject().CanCollide(c.GetObject()) {
+					found = append(found, c)
+				}
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return s.entries[found[i]].index < s.entries[found[j]].index
+	})
+	return found
+}
+
+func (s *SpatialHash) insert(o GameObject, e *hashEntry) {
+	for y := e.y0; y <= e.y1; y++ {
+		for x := e.x0; x <= e.x1; x++ {
+			i := x + y*s.cols
+			s.cells[i] = append(s.cells[i], o)
+		}
+	}
+}
+
+func (s *SpatialHash) remove(o GameObject, e *hashEntry) {
+	for y := e.y0; y <= e.y1; y++ {
+		for x := e.x0; x <= e.x1; x++ {
+			i := x + y*s.cols
+			cell := s.cells[i]
+			for j, c := range cell {
+				if c == o {
+					s.cells[i] = append(cell[:j], cell[j+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
+
+// cellRange returns the first and last cell column and row a rect (grown by margin) covers
+func (s *SpatialHash) cellRange(r *Rect, margin int) (x0, y0, x1, y1 int) {
+	x0 = s.clamp((r.x-margin)/s.cell, s.cols)
+	y0 = s.clamp((r.y-margin)/s.cell, s.rows)
+	x1 = s.clamp((r.x+r.w+margin)/s.cell, s.cols)
+	y1 = s.clamp((r.y+r.h+margin)/s.cell, s.rows)
+	return
+}
+
+func (s *SpatialHash) clamp(i, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
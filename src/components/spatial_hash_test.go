@@ -0,0 +1,197 @@
+package com
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	sha "moonlander/src/shared"
+)
+
+// level size and object count of the collision benchmarks
+const (
+	benchWidth, benchHeight = 4000, 2000
+	benchStatic             = 400
+	benchMoving             = 20
+)
+
+func newTestObject(x, y, w, h int, layer, mask Layer) *Object {
+	o := NewObject(0, nil, x, y, 0, Vector{}, 0, 0, w, h, true, color.RGBA{})
+	o.SetLayers(layer, mask)
+	return &o
+}
+
+// benchObjects returns a few hundred static walls, and some moving ships which collide with them
+func benchObjects() (static, moving []GameObject) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < benchStatic; i++ {
+		static = append(static, newTestObject(r.Intn(benchWidth), r.Intn(benchHeight), 16+r.Intn(64), 16+r.Intn(64), LayerTerrain, LayerNone))
+	}
+	for i := 0; i < benchMoving; i++ {
+		o := newTestObject(r.Intn(benchWidth), r.Intn(benchHeight), 32, 32, LayerShip, LayerTerrain)
+		// up to 4 pixels a tick
+		o.Vector = Vector{r.Float64()*8 - 4, r.Float64()*8 - 4}.scale(sha.TPS)
+		moving = append(moving, o)
+	}
+	return static, moving
+}
+
+// count the candidates which overlap, so the work on the candidates is part of the benchmark
+func countOverlaps(o GameObject, candidates []GameObject) int {
+	n := 0
+	for _, c := range candidates {
+		if CheckOverlap(o.GetObject().GetRect(), c.GetObject().GetRect()) {
+			n++
+		}
+	}
+	return n
+}
+
+// every moving object is checked against the full list of objects
+func BenchmarkCollideFull(b *testing.B) {
+	static, moving := benchObjects()
+	all := append(static, moving...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, o := range moving {
+			o.Update(nil)
+			var candidates []GameObject
+			for _, c := range all {
+				if c != o && o.GetObject().CanCollide(c.GetObject()) {
+					candidates = append(candidates, c)
+				}
+			}
+			countOverlaps(o, candidates)
+		}
+	}
+}
+
+// every moving object is checked against the objects in the cells around it
+func BenchmarkCollideHash(b *testing.B) {
+	static, moving := benchObjects()
+	s := NewSpatialHash(benchWidth, benchHeight, 64)
+	for _, o := range static {
+		s.Add(o, false)
+	}
+	for _, o := range moving {
+		s.Add(o, true)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, o := range moving {
+			o.Update(nil)
+		}
+		s.Update()
+		for _, o := range moving {
+			countOverlaps(o, s.Query(o))
+		}
+	}
+}
+
+func TestQueryMaskAndOrder(t *testing.T) {
+	s := NewSpatialHash(1000, 1000, 64)
+	ship := newTestObject(100, 100, 32, 32, LayerShip, LayerTerrain|LayerTrigger)
+	// added in reverse cell order, the result has to keep the order of adding
+	wallB := newTestObject(120, 120, 32, 32, LayerTerrain, LayerNone)
+	trigger := newTestObject(110, 90, 32, 32, LayerTrigger, LayerNone)
+	debris := newTestObject(100, 110, 32, 32, LayerDebris, LayerNone)
+	wallA := newTestObject(60, 60, 64, 64, LayerTerrain, LayerNone)
+	far := newTestObject(800, 800, 32, 32, LayerTerrain, LayerNone)
+	for _, o := range []*Object{wallB, trigger, ship, debris, wallA, far} {
+		s.Add(o, o == ship)
+	}
+
+	got := s.Query(ship)
+	want := []GameObject{wallB, trigger, wallA}
+	if len(got) != len(want) {
+		t.Fatalf("Query returned %d objects, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Query result %d is %p, want %p", i, got[i], want[i])
+		}
+	}
+}
+
+// queryWorld returns a hash with two walls and a trigger in a row, and a wall in the top left corner
+func queryWorld() (s *SpatialHash, wallA, wallB, trigger, corner *Object) {
+	s = NewSpatialHash(1000, 1000, 64)
+	trigger = newTestObject(200, 100, 20, 100, LayerTrigger, LayerNone)
+	wallA = newTestObject(300, 100, 20, 100, LayerTerrain, LayerNone)
+	wallB = newTestObject(500, 100, 20, 100, LayerTerrain, LayerNone)
+	corner = newTestObject(0, 0, 10, 10, LayerTerrain, LayerNone)
+	for _, o := range []*Object{trigger, wallA, wallB, corner} {
+		s.Add(o, false)
+	}
+	return s, wallA, wallB, trigger, corner
+}
+
+func TestRaycast(t *testing.T) {
+	s, wallA, wallB, trigger, _ := queryWorld()
+	tests := []struct {
+		name        string
+		origin, dir Vector
+		maxDist     float64
+		mask        Layer
+		want        *Object
+		dist        float64
+		normal      Vector
+	}{
+		{"hit", Vector{0, 150}, Vector{1, 0}, 1000, LayerTerrain, wallA, 300, Vector{-1, 0}},
+		{"mask", Vector{0, 150}, Vector{1, 0}, 1000, LayerTrigger, trigger, 200, Vector{-1, 0}},
+		{"back", Vector{900, 150}, Vector{-1, 0}, 1000, LayerAll, wallB, 380, Vector{1, 0}},
+		{"too short", Vector{0, 150}, Vector{1, 0}, 250, LayerTerrain, nil, 0, Vector{}},
+		{"miss", Vector{0, 150}, Vector{0, 1}, 1000, LayerAll, nil, 0, Vector{}},
+		{"from outside", Vector{-100, 150}, Vector{1, 0}, 1000, LayerTerrain, wallA, 400, Vector{-1, 0}},
+		{"past the level", Vector{-100, 150}, Vector{-1, 0}, 1000, LayerAll, nil, 0, Vector{}},
+		{"beside the level", Vector{-100, -50}, Vector{1, 0}, 1000, LayerAll, nil, 0, Vector{}},
+	}
+	for _, tt := range tests {
+		hit, ok := s.Raycast(tt.origin, tt.dir, tt.maxDist, tt.mask)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("%s: hit %p, want a miss", tt.name, hit.Object)
+			}
+			continue
+		}
+		if !ok || hit.Object != tt.want {
+			t.Errorf("%s: hit %v %p, want %p", tt.name, ok, hit.Object, tt.want)
+			continue
+		}
+		if math.Abs(hit.Distance-tt.dist) > 1e-9 || hit.Normal != tt.normal {
+			t.Errorf("%s: distance %v normal %v, want %v %v", tt.name, hit.Distance, hit.Normal, tt.dist, tt.normal)
+		}
+	}
+}
+
+func TestQueryRectCircle(t *testing.T) {
+	s, wallA, wallB, trigger, corner := queryWorld()
+	tests := []struct {
+		name string
+		got  []GameObject
+		want []*Object
+	}{
+		{"rect", s.QueryRect(210, 120, 100, 10, LayerAll), []*Object{trigger, wallA}},
+		{"rect mask", s.QueryRect(210, 120, 100, 10, LayerTerrain), []*Object{wallA}},
+		{"rect miss", s.QueryRect(600, 600, 50, 50, LayerAll), nil},
+		{"rect outside", s.QueryRect(-50, -50, 55, 55, LayerAll), []*Object{corner}},
+		{"circle", s.QueryCircle(Vector{490, 150}, 15, LayerAll), []*Object{wallB}},
+		{"circle inside", s.QueryCircle(Vector{310, 150}, 2, LayerAll), []*Object{wallA}},
+		{"circle mask", s.QueryCircle(Vector{490, 150}, 15, LayerTrigger), nil},
+		// the bounding rect of the circle overlaps the wall, the circle misses its corner
+		{"circle corner miss", s.QueryCircle(Vector{290, 90}, 10, LayerAll), nil},
+		{"circle outside", s.QueryCircle(Vector{-5, -5}, 8, LayerAll), []*Object{corner}},
+	}
+	for _, tt := range tests {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s: found %d objects, want %d", tt.name, len(tt.got), len(tt.want))
+			continue
+		}
+		for i := range tt.want {
+			if tt.got[i] != tt.want[i] {
+				t.Errorf("%s: object %d is %p, want %p", tt.name, i, tt.got[i], tt.want[i])
+			}
+		}
+	}
+}
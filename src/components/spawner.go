@@ -0,0 +1,51 @@
+package com
+
+import (
+	sha "moonlander/src/shared"
+)
+
+// Spawner is a level region in which entities are spawned when the level loads
+// it is level data only, it is not drawn, updated or hit. A Seed of 0 spawns differently every load
+type Spawner struct {
+	Sprite
+	// id of the spawner object in Tiled, the sprite ID is the type of the spawner
+	ObjectID   int
+	region     Rect
+	Count      int
+	Size       int
+	VMin, VMax float64
+	Entity     string
+	Seed       int64
+}
+
+func init() {
+	Register("spawner", func(a ItemArgs) (GameObject, error) {
+		o := NewSpawner(sha.IDSpawner, a.X, a.Y, a.W, a.H, a.Props)
+		o.ObjectID = a.ID
+		return &o, nil
+	})
+}
+
+// NewSpawner constructor, reads the spawn settings from the properties
+func NewSpawner(id, x, y, w, h int, p sha.Props) Spawner {
+	return Spawner{
+		Sprite: NewSprite(id, nil, x, y, 0, Vector{}),
+		region: NewRect(x, y, w, h),
+		Count:  p.GetInt("count", 0),
+		Size:   p.GetInt("size", 32),
+		VMin:   p.GetFloat("vMin", 0),
+		VMax:   p.GetFloat("vMax", 0),
+		Entity: p.GetString("entity", "square"),
+		Seed:   int64(p.GetInt("seed", 0)),
+	}
+}
+
+// GetInfo implements interface
+func (o *Spawner) GetInfo() (id int, name string, x, y, r float64, w, h int) {
+	return o.ID, sha.Name[o.ID], o.X, o.Y, o.R, o.region.w, o.region.h
+}
+
+// GetRegion returns the spawn region as x, y, w, h
+func (o *Spawner) GetRegion() (x, y, w, h int) {
+	return o.region.x, o.region.y, o.region.w, o.region.h
+}
@@ -2,6 +2,8 @@ package com
 
 import (
 	"image/color"
+
+	sha "moonlander/src/shared"
 )
 
 // Square is <dunno yet>
@@ -9,38 +11,36 @@ type Square struct {
 	Object
 }
 
+func init() {
+	Register("square", func(a ItemArgs) (GameObject, error) {
+		p := a.Props
+		// vx and vy are in pixels per tick at tuneTPS
+		v := NewVector(p.GetFloat("vx", 0)*tuneTPS, p.GetFloat("vy", 0)*tuneTPS)
+		o := NewSquare(sha.IDSquare, a.X, a.Y, ZDebris, v, 0, 0, a.W, a.H, p.GetColor("color", sha.Purple50))
+		return &o, nil
+	})
+}
+
 // NewSquare constructor
 func NewSquare(id, x, y, z int, v Vector, rx, ry, rw, rh int, c color.RGBA) Square {
-	return Square{Object: NewObject(id, nil, x, y, z, v, rx, ry, rw, rh, true, c)}
+	o := Square{Object: NewObject(id, nil, x, y, z, v, rx, ry, rw, rh, true, c)}
+	o.SetLayers(LayerDebris, LayerTerrain|LayerShip|LayerDebris)
+	return o
 }
 
-// Collide implements interface Collider
+// Collide implements interface Collider, bounces off solid objects (reflect velocity on the surface normal)
 func (o *Square) Collide(hitAbles []GameObject) error {
 
 	for _, h := range hitAbles {
 		t := h.GetObject()
-		if &o.rect != &t.rect {
-			hit, sides := CheckHit(o.GetObject(), t, true, true)
-			if hit {
-				// fmt.Printf("%s hits %s on sides %+v\n", con.ID[o.ID], con.ID[t.ID], sides)
-				if t.solid {
-					if sides.left {
-						o.X = float64(t.rect.x-o.rect.w-o.rx) - 1
-						o.Vector.x *= -1
-					}
-					if sides.right {
-						o.X = float64(t.rect.x+t.rect.w-o.rx) + 1
-						o.Vector.x *= -1
-					}
-					if sides.top {
-						o.Y = float64(t.rect.y-o.rect.h-o.ry) - 1
-						o.Vector.y *= -1
-					}
-					if sides.bottom {
-						o.Y = float64(t.rect.y+t.rect.h-o.ry) + 1
-						o.Vector.y *= -1
-					}
-				}
+		if t == o.GetObject() || !t.solid {
+			continue
+		}
+		for _, c := range CheckShapes(o.GetObject(), t, GetHitShapes(h)) {
+			o.addContact(h, c)
+			o.pushOut(c)
+			if vn := o.Vector.dot(c.Normal); vn < 0 {
+				o.Vector = o.Vector.sub(c.Normal.scale(2 * vn))
 			}
 		}
 	}
@@ -0,0 +1,94 @@
+package com
+
+import (
+	"errors"
+	"image/color"
+	"math"
+
+	sha "moonlander/src/shared"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/vector"
+)
+
+// Terrain is static level geometry from a Tiled polygon or polyline,
+// it is drawn as a filled shape (or thick line) and collides on its edges
+type Terrain struct {
+	Object
+	shapes [][]Vector
+}
+
+func init() {
+	Register("terrain", func(a ItemArgs) (GameObject, error) {
+		if len(a.Points) < 2 {
+			return nil, errors.New("terrain needs a polygon or polyline")
+		}
+		o := NewTerrain(sha.IDTerrain, a.Points, a.Closed, a.Props.GetFloat("width", 4), a.Props.GetColor("color", sha.Blue50))
+		return &o, nil
+	})
+}
+
+// NewTerrain constructor, points are in world coordinates,
+// closed is a filled polygon, otherwise it is a line of width pixels
+func NewTerrain(id int, points []Vector, closed bool, width float64, c color.RGBA) Terrain {
+	// hit rect is the bounding box, padded so a straight line still has a size
+	pad := width / 2
+	b := getBounds(points)
+	p := int(math.Ceil(pad))
+	x, y, w, h := b.x-p, b.y-p, b.w+2*p, b.h+2*p
+
+	// draw the shape once, relative to the hit rect
+	img, _ := ebiten.NewImage(w, h, ebiten.FilterDefault)
+	offset := Vector{float64(x), float64(y)}
+	var path vector.Path
+	if closed {
+		addPolygon(&path, points, offset)
+	} else {
+		// a line is a quad per segment
+		for i := 0; i < len(points)-1; i++ {
+			a, b := points[i], points[i+1]
+			n := b.sub(a).perp().normalize().scale(pad)
+			addPolygon(&path, []Vector{a.add(n), b.add(n), b.sub(n), a.sub(n)}, offset)
+		}
+	}
+	path.Fill(img, &vector.FillOptions{Color: c})
+
+	// every edge is a line segment, so concave polygons collide on their real outline
+	var shapes [][]Vector
+	for i := 0; i < len(points)-1; i++ {
+		shapes = append(shapes, []Vector{points[i], points[i+1]})
+	}
+	if closed && len(points) > 2 {
+		shapes = append(shapes, []Vector{points[len(points)-1], points[0]})
+	}
+
+	return Terrain{
+		Object: NewObject(id, img, x, y, ZTerrain, Vector{}, 0, 0, w, h, true, c),
+		shapes: shapes,
+	}
+}
+
+// addPolygon adds a closed polygon to a path, offset is subtracted from all points
+func addPolygon(path *vector.Path, points []Vector, offset Vector) {
+	for i, p := range points {
+		p = p.sub(offset)
+		if i == 0 {
+			path.MoveTo(float32(p.x), float32(p.y))
+		} else {
+			path.LineTo(float32(p.x), float32(p.y))
+		}
+	}
+}
+
+// Draw implements interface, only the shape (the hit rect is just a bounding box)
+func (o *Terrain) Draw(screen *ebiten.Image) error {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(o.X, o.Y)
+	screen.DrawImage(o.Img, op)
+	return nil
+}
+
+// GetShapes implements Shaped
+func (o *Terrain) GetShapes() [][]Vector {
+	return o.shapes
+}
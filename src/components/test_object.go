@@ -11,26 +11,41 @@ import (
 
 // TestObject is used for testing collisions, its a controllable square
 type TestObject struct {
-	speed, imgHW, imgHH             float64
-	hitX, hitY, hitW, hitH, hitDiff int
-	collideObject                   *Object
+	speed, imgHW, imgHH float64
+	hitW, hitH          int
+	input               Input
 	Object
 }
 
+func init() {
+	Register("tester", func(a ItemArgs) (GameObject, error) {
+		p := a.Props
+		o := NewCollideTest(sha.IDTester, a.X, a.Y, ZShip, Vector{},
+			p.GetInt("hitX", 4), p.GetInt("hitY", 4), p.GetInt("hitW", 24), p.GetInt("hitH", 56),
+			p.GetColor("color", sha.Green50))
+		// the keys of the tester aren't recorded in replays, it is only steered in debug mode
+		if sha.Debug {
+			o.input = testerKeys{}
+		}
+		return &o, nil
+	})
+}
+
 // NewCollideTest constructor
 func NewCollideTest(id, x, y, z int, v Vector, rx, ry, rw, rh int, c color.RGBA) TestObject {
-	return TestObject{
+	o := TestObject{
 		Object: NewObject(id, nil, x, y, z, v, rx, ry, rw, rh, true, c),
-		speed:  0.8,
+		speed:  48,
 		imgHW:  float64(rw/2 + rx),
 		imgHH:  float64(rh/2 + ry),
-		// keep original hit rect values, to calc rotating hit rect
-		hitX:    rx,
-		hitY:    ry,
-		hitW:    rw,
-		hitH:    rh,
-		hitDiff: (rw - rh) / 2,
+		// keep original hit box size, to calc rotating hit shape
+		hitW: rw,
+		hitH: rh,
 	}
+	o.rectImg.Fill(sha.Cyan50)
+	o.SetLayers(LayerShip, LayerTerrain|LayerDebris)
+	o.rotateShape(o.X+o.imgHW, o.Y+o.imgHH, 0, rw, rh)
+	return o
 }
 
 //Draw overrides Drawable interface (from default Sprite)
@@ -43,13 +58,12 @@ func (o *TestObject) Draw(screen *ebiten.Image) error {
 		op.GeoM.Translate(o.X+o.imgHW, o.Y+o.imgHH)
 		screen.DrawImage(o.Img, op)
 	}
-	// draw hit rect
+	// draw hit shape, rotated like the image
 	if o.rectImg != nil {
-		// need to recreate image, because it changes shape (expensive only use for debug)
-		o.rectImg, _ = ebiten.NewImage(o.rect.w, o.rect.h, ebiten.FilterNearest)
-		o.rectImg.Fill(sha.Cyan50)
 		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(float64(o.rect.x), float64(o.rect.y))
+		op.GeoM.Translate(float64(o.rx)-o.imgHW, float64(o.ry)-o.imgHH)
+		op.GeoM.Rotate(o.R)
+		op.GeoM.Translate(o.X+o.imgHW, o.Y+o.imgHH)
 		screen.DrawImage(o.rectImg, op)
 	}
 	return nil
@@ -57,95 +71,82 @@ func (o *TestObject) Draw(screen *ebiten.Image) error {
 
 // Update ..
 func (o *TestObject) Update(screen *ebiten.Image) error {
-	o.removeHit()
-
 	// slow down
-	o.Vector.x *= 0.9
-	o.Vector.y *= 0.9
+	o.Vector = o.Vector.scale(damp(0.9))
 
-	if ebiten.IsKeyPressed(ebiten.KeyT) {
+	var c Controls
+	if o.input != nil {
+		c = o.input.Read()
+	}
+	if c.up {
 		o.Vector.y = o.speed * -1
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyG) {
+	if c.down {
 		o.Vector.y = o.speed
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyF) {
+	if c.left {
 		o.Vector.x = o.speed * -1
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyH) {
+	if c.right {
 		o.Vector.x = o.speed
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyR) {
-		o.R -= (o.speed * 2) * DegToRad
+	if c.rl {
+		o.R -= (o.speed * 2) * sha.DT * DegToRad
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyY) {
-		o.R += (o.speed * 2) * DegToRad
+	if c.rr {
+		o.R += (o.speed * 2) * sha.DT * DegToRad
 	}
 	if math.Abs(o.R) > DPI {
 		o.R = 0
 	}
 
-	zx := math.Sin(o.R)
-	zy := math.Cos(o.R)
-
-	nzx := math.Pow(zx, 2)
-	nzy := math.Pow(zy, 2)
-	o.rect.w = int(float64(o.hitH)*nzx + float64(o.hitW)*nzy)
-	o.rect.h = int(float64(o.hitW)*nzx + float64(o.hitH)*nzy)
-	o.rx = o.hitX + int(float64(o.hitDiff)*nzx)
-	o.ry = o.hitY + int(float64(o.hitDiff)*nzx)*-1
-
 	// update position
-	o.X += o.Vector.x
-	o.Y += o.Vector.y
+	o.moved = o.Vector.scale(sha.DT)
+	o.X += o.moved.x
+	o.Y += o.moved.y
 
-	// update hit rect
-	o.rect.setXY(int(o.X)+o.rx, int(o.Y)+o.ry)
+	// update hit shape, the hit box rotates with the image around its center
+	o.rotateShape(o.X+o.imgHW, o.Y+o.imgHH, o.R, o.hitW, o.hitH)
 	return nil
 }
 
 //Collide implements interface Collider, handles collission with ojects
 func (o *TestObject) Collide(hitAbles []GameObject) error {
 
+	// stop at the first solid object in the way
+	if _, n, ok := o.sweep(hitAbles); ok {
+		if vn := o.Vector.dot(n); vn < 0 {
+			o.Vector = o.Vector.sub(n.scale(vn))
+		}
+	}
 	for _, h := range hitAbles {
 		t := h.GetObject()
-		if &o.rect != &t.rect {
-			hit, sides := CheckHit(o.GetObject(), t, true, true)
-			if hit {
-				// fmt.Printf("%s hits %s on sides %+v\n", sha.ID[o.ID], sha.ID[t.ID], sides)
-				if t.solid {
-					o.addHit(t)
-					if sides.left {
-						o.X = float64(t.rect.x-o.rect.w-o.rx) - 1
-						o.Vector.x = 0
-					}
-					if sides.right {
-						o.X = float64(t.rect.x+t.rect.w-o.rx) + 1
-						o.Vector.x = 0
-					}
-					if sides.top {
-						o.Y = float64(t.rect.y-o.rect.h-o.ry) - 1
-						o.Vector.y = 0
-					}
-					if sides.bottom {
-						o.Y = float64(t.rect.y+t.rect.h-o.ry) + 1
-						o.Vector.y = 0
-					}
-				}
+		if t == o.GetObject() || !t.solid {
+			continue
+		}
+		// push out along the contact normal, and stop moving into the target
+		for _, c := range CheckShapes(o.GetObject(), t, GetHitShapes(h)) {
+			o.addContact(h, c)
+			o.pushOut(c)
+			if vn := o.Vector.dot(c.Normal); vn < 0 {
+				o.Vector = o.Vector.sub(c.Normal.scale(vn))
 			}
 		}
 	}
 	return nil
 }
 
-func (o *TestObject) addHit(obj *Object) {
-	obj.Hit = true
-	o.collideObject = obj
-}
-
-func (o *TestObject) removeHit() {
-	if o.collideObject != nil {
-		o.collideObject.Hit = false
-		o.collideObject = nil
+// testerKeys steers the tester from the keyboard, T, G, F, H to move and R, Y to rotate
+type testerKeys struct{}
+
+// Read implements Input
+func (testerKeys) Read() Controls {
+	return Controls{
+		up:    ebiten.IsKeyPressed(ebiten.KeyT),
+		down:  ebiten.IsKeyPressed(ebiten.KeyG),
+		left:  ebiten.IsKeyPressed(ebiten.KeyF),
+		right: ebiten.IsKeyPressed(ebiten.KeyH),
+		rl:    ebiten.IsKeyPressed(ebiten.KeyR),
+		rr:    ebiten.IsKeyPressed(ebiten.KeyY),
 	}
 }
@@ -16,23 +16,24 @@ import (
 )
 
 var (
-	face     font.Face
-	laps     Text
-	laptime  Text
-	gravity  Text
-	friction Text
-	fps      Text
-	endTimes string
+	face font.Face
 )
 
+// ticks the split delta is shown
+const deltaTicks = 3 * sha.TPS
+
 // duration formater, stores duration as total MS, and seperate min, sec, ms
 type duration struct {
 	total, min, sec, ms int
 }
 
-// TextBlock is a positioal holder for multiple textblocks
+// TextBlock is a positioal holder for multiple textblocks, showing the properties of a level
 type TextBlock struct {
-	x, y int
+	x, y                                  int
+	laps, laptime, gravity, friction, fps Text
+	endTimes                              string
+	leaderboard, delta                    Text
+	lp                                    *sha.LevelProperties
 }
 
 func init() {
@@ -49,14 +50,21 @@ func init() {
 }
 
 // NewTextBlock contructor
-func NewTextBlock(x, y int) TextBlock {
+func NewTextBlock(x, y int, lp *sha.LevelProperties) TextBlock {
 	// setup textboxes
-	fps = NewText(0, 0, "", face, sha.White)
-	gravity = NewText(0, 20, "", face, sha.White)
-	friction = NewText(0, 40, "", face, sha.White)
-	laps = NewText(0, 60, "", face, sha.White)
-	laptime = NewText(0, 80, "", face, sha.White)
-	return TextBlock{x, y}
+	return TextBlock{
+		x:        x,
+		y:        y,
+		fps:      NewText(0, 0, "", face, sha.White),
+		gravity:  NewText(0, 20, "", face, sha.White),
+		friction: NewText(0, 40, "", face, sha.White),
+		laps:     NewText(0, 60, "", face, sha.White),
+		laptime:  NewText(0, 80, "", face, sha.White),
+		// next to the end times
+		leaderboard: NewText(160, 80, "", face, sha.White),
+		delta:       NewText(110, 80, "", face, sha.White),
+		lp:          lp,
+	}
 }
 
 // GetID implements interface
@@ -73,35 +81,72 @@ func (o *TextBlock) GetInfo() (id int, name string, x, y, r float64, w, h int) {
 func (o *TextBlock) Draw(screen *ebiten.Image) error {
 
 	// update laps
-	laps.text = "LAP: " + strconv.Itoa(sha.LP.CurrentLap)
+	o.laps.text = "LAP: " + strconv.Itoa(o.lp.CurrentLap)
 
 	// update lap laptime
-	if !sha.LP.LapStartTime.IsZero() {
-		if sha.LP.CurrentLap > sha.LP.MaxLaps {
-			if endTimes == "" {
-				endTimes = calcEndTimes()
+	if o.lp.LapStarted {
+		if o.lp.CurrentLap > o.lp.MaxLaps {
+			if o.endTimes == "" {
+				o.endTimes = calcEndTimes(o.lp.LapTicks)
 			}
-			laptime.text = endTimes
+			o.laptime.text = o.endTimes
 		} else {
-			et := getElapsedTime(sha.LP.LapStartTime)
-			laptime.text = fmt.Sprintf("%02d:%02d.%03d", et.min, et.sec, et.ms)
+			et := fmtDuration(sha.TicksToDuration(o.lp.Clock.Tick - o.lp.LapStartTick))
+			o.laptime.text = fmt.Sprintf("%02d:%02d.%03d", et.min, et.sec, et.ms)
+			o.updateDelta()
 		}
 	}
 
 	// update other textfields
-	fps.text = fmt.Sprintf("%.2f", ebiten.CurrentTPS())
-	gravity.text = fmt.Sprintf("%.3fG", (sha.LP.Gravity * 50))
-	friction.text = fmt.Sprintf("%.3fF", sha.LP.Friction)
+	o.fps.text = fmt.Sprintf("%.2f", ebiten.CurrentTPS())
+	o.gravity.text = fmt.Sprintf("%.3fG", (o.lp.Gravity * 50))
+	o.friction.text = fmt.Sprintf("%.3fF", o.lp.Friction)
 
 	// draw
-	text.Draw(screen, fps.text, face, o.x+fps.x, o.y+fps.y, laps.color)
-	text.Draw(screen, gravity.text, face, o.x+gravity.x, o.y+gravity.y, laps.color)
-	text.Draw(screen, friction.text, face, o.x+friction.x, o.y+friction.y, laps.color)
-	text.Draw(screen, laps.text, face, o.x+laps.x, o.y+laps.y, laps.color)
-	text.Draw(screen, laptime.text, face, o.x+laptime.x, o.y+laptime.y, laptime.color)
+	text.Draw(screen, o.fps.text, face, o.x+o.fps.x, o.y+o.fps.y, o.laps.color)
+	text.Draw(screen, o.gravity.text, face, o.x+o.gravity.x, o.y+o.gravity.y, o.laps.color)
+	text.Draw(screen, o.friction.text, face, o.x+o.friction.x, o.y+o.friction.y, o.laps.color)
+	text.Draw(screen, o.laps.text, face, o.x+o.laps.x, o.y+o.laps.y, o.laps.color)
+	text.Draw(screen, o.laptime.text, face, o.x+o.laptime.x, o.y+o.laptime.y, o.laptime.color)
+	if o.lp.CurrentLap <= o.lp.MaxLaps && o.delta.text != "" {
+		text.Draw(screen, o.delta.text, face, o.x+o.delta.x, o.y+o.delta.y, o.delta.color)
+	}
+	if o.lp.CurrentLap > o.lp.MaxLaps {
+		text.Draw(screen, o.leaderboard.text, face, o.x+o.leaderboard.x, o.y+o.leaderboard.y, o.leaderboard.color)
+	}
 	return nil
 }
 
+// updateDelta shows the difference of the last split time with the best split of the same checkpoint,
+// for a while after the split
+func (o *TextBlock) updateDelta() {
+	o.delta.text = ""
+	// the last passed checkpoint has the latest split
+	i := -1
+	for j, s := range o.lp.Splits {
+		if s >= 0 && (i < 0 || s > o.lp.Splits[i]) {
+			i = j
+		}
+	}
+	if i < 0 || i >= len(o.lp.BestSplits) || o.lp.BestSplits[i] < 0 || o.lp.Clock.Tick-o.lp.LapStartTick-o.lp.Splits[i] > deltaTicks {
+		return
+	}
+	d := o.lp.Splits[i] - o.lp.BestSplits[i]
+	o.delta.text, o.delta.color = "-", sha.Green
+	if d >= 0 {
+		o.delta.text, o.delta.color = "+", sha.Red
+	} else {
+		d = -d
+	}
+	ms := int(sha.TicksToDuration(d).Milliseconds())
+	o.delta.text += fmt.Sprintf("%d.%02d", ms/1000, ms%1000/10)
+}
+
+// SetLeaderboard sets the records shown at the end of the race
+func (o *TextBlock) SetLeaderboard(s string) {
+	o.leaderboard.text = s
+}
+
 // Update implements interface
 func (o *TextBlock) Update(screen *ebiten.Image) error {
 	// gui is updated in draw
@@ -113,21 +158,11 @@ func (o *TextBlock) GetObject() *Object {
 	return nil
 }
 
-// SetHit implements interface
-func (o *TextBlock) SetHit(collider GameObject) {
-}
-
 // Collide implements interface
 func (o *TextBlock) Collide(hitAbles []GameObject) error {
 	return nil
 }
 
-// Get elapsed time from a start time
-func getElapsedTime(t time.Time) duration {
-	et := time.Now().Sub(t)
-	return fmtDuration(et)
-}
-
 // fmt duration in total ms, min, sec, ms
 func fmtDuration(et time.Duration) duration {
 	total := int(et.Milliseconds())
@@ -139,10 +174,11 @@ func fmtDuration(et time.Duration) duration {
 	}
 }
 
-func calcEndTimes() string {
+func calcEndTimes(lapTicks []int) string {
 	var str string
 	var tt time.Duration
-	for _, lt := range sha.LP.LapTimes {
+	for _, ticks := range lapTicks {
+		lt := sha.TicksToDuration(ticks)
 		tt += lt
 		lap := fmtDuration(lt)
 		str += fmt.Sprintf("%02d:%02d.%03d\n", lap.min, lap.sec, lap.ms)
@@ -2,6 +2,8 @@ package com
 
 import (
 	"image/color"
+
+	sha "moonlander/src/shared"
 )
 
 // Wall is something you can smack in to
@@ -9,7 +11,15 @@ type Wall struct {
 	Object
 }
 
+func init() {
+	Register("wall", func(a ItemArgs) (GameObject, error) {
+		o := NewWall(sha.IDWall, a.X, a.Y, a.W, a.H, a.Props.GetColor("color", sha.Blue50))
+		o.SetRotation(a.Rotation * DegToRad)
+		return &o, nil
+	})
+}
+
 // NewWall constructor
 func NewWall(id, x, y, w, h int, c color.RGBA) Wall {
-	return Wall{Object: NewObject(id, nil, x, y, 0, Vector{}, 0, 0, w, h, true, c)}
+	return Wall{Object: NewObject(id, nil, x, y, ZTerrain, Vector{}, 0, 0, w, h, true, c)}
 }
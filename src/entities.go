@@ -0,0 +1,182 @@
+package src
+
+import (
+	"fmt"
+
+	com "moonlander/src/components"
+	sha "moonlander/src/shared"
+)
+
+// Handle is a stable id of an entity in a World, it is never reused,
+// so a handle of a destroyed entity just doesn't find anything anymore
+type Handle uint64
+
+// an entity waiting to be added, p decides the lists it is added to, layer is its Tiled draw layer
+type pendingSpawn struct {
+	handle Handle
+	item   com.GameObject
+	props  sha.Props
+	layer  int
+}
+
+// Spawn creates an entity of a Tiled type, with the object type defaults of the level under the properties in a.
+// The entity is added to the world after the current tick, until then Get doesn't find the handle
+func (w *World) Spawn(typ string, a com.ItemArgs) (Handle, error) {
+	p := getItemProps(typ, nil, nil, w.objectTypes)
+	p.Merge(a.Props)
+	a.Props, a.Level = p, &w.LP
+	if name, err := p.Check(); err != nil {
+		return 0, fmt.Errorf("spawn %q, property %q: %v", typ, name, err)
+	}
+	o, err := com.Construct(typ, a)
+	if err != nil {
+		return 0, fmt.Errorf("spawn %q: %v", typ, err)
+	}
+	if name, err := setItemLayers(o, p); err != nil {
+		return 0, fmt.Errorf("spawn %q, property %q: %v", typ, name, err)
+	}
+	setItemZ(o, p)
+	return w.SpawnObject(o, p), nil
+}
+
+// SpawnObject adds an entity created in code after the current tick (or after loading),
+// the draw, hit, update and collide properties decide the lists it is added to
+func (w *World) SpawnObject(o com.GameObject, p sha.Props) Handle {
+	w.nextHandle++
+	w.spawnQueue = append(w.spawnQueue, pendingSpawn{w.nextHandle, o, p, w.loadLayer})
+	return w.nextHandle
+}
+
+// Destroy removes an entity after the current tick, destroying an entity twice does nothing
+func (w *World) Destroy(h Handle) {
+	w.destroyQueue = append(w.destroyQueue, h)
+}
+
+// Get returns the entity of a handle, when it is (still) in the world
+func (w *World) Get(h Handle) (com.GameObject, bool) {
+	o, ok := w.entities[h]
+	return o, ok
+}
+
+// HandleOf returns the handle of an entity in the world
+func (w *World) HandleOf(o com.GameObject) (Handle, bool) {
+	h, ok := w.handles[o]
+	return h, ok
+}
+
+// applyQueues destroys and spawns the queued entities, between ticks when no list is iterated
+func (w *World) applyQueues() {
+	destroy := w.destroyQueue
+	w.destroyQueue = nil
+	for _, h := range destroy {
+		w.removeEntity(h)
+	}
+	spawn := w.spawnQueue
+	w.spawnQueue = nil
+	for _, s := range spawn {
+		if !contains(destroy, s.handle) {
+			w.addEntity(s.handle, s.item, s.props, s.layer)
+		}
+	}
+}
+
+// Add an entity to the correct lists, based on the resolved properties in Tiled
+func (w *World) addEntity(h Handle, item com.GameObject, p sha.Props, layer int) {
+	w.entities[h] = item
+	w.handles[item] = h
+
+	// keep track of objects the level needs to know about
+	switch t := item.(type) {
+	case *com.Player:
+		w.player = t
+	case *com.Checkpoint:
+		w.checkpoints = append(w.checkpoints, t)
+		if w.finish != nil {
+			w.finish.SetCheckpoints(w.checkpoints)
+		}
+	case *com.Finish:
+		w.finish = t
+		t.SetCheckpoints(w.checkpoints)
+	case *com.Spawner:
+		w.spawners = append(w.spawners, t)
+	}
+
+	if p.GetBool("draw", false) {
+		w.addDrawable(item, layer)
+	}
+	if p.GetBool("hit", false) {
+		w.HitAbleList = append(w.HitAbleList, item)
+		// everything that is updated can move
+		w.hitHash.Add(item, p.GetBool("update", false))
+	}
+	if p.GetBool("update", false) {
+		w.UpdateList = append(w.UpdateList, item)
+	}
+	if p.GetBool("collide", false) {
+		w.CollideList = append(w.CollideList, item)
+	}
+}
+
+// Remove an entity from all lists
+func (w *World) removeEntity(h Handle) {
+	item, ok := w.entities[h]
+	if !ok {
+		return
+	}
+	delete(w.entities, h)
+	delete(w.handles, item)
+
+	switch t := item.(type) {
+	case *com.Player:
+		w.player = nil
+	case *com.Checkpoint:
+		for i, c := range w.checkpoints {
+			if c == t {
+				w.checkpoints = append(w.checkpoints[:i:i], w.checkpoints[i+1:]...)
+				break
+			}
+		}
+		if w.finish != nil {
+			w.finish.SetCheckpoints(w.checkpoints)
+		}
+	case *com.Finish:
+		w.finish = nil
+	case *com.Spawner:
+		for i, s := range w.spawners {
+			if s == t {
+				w.spawners = append(w.spawners[:i:i], w.spawners[i+1:]...)
+				break
+			}
+		}
+	}
+
+	w.DrawWorldList = removeObject(w.DrawWorldList, item)
+	delete(w.drawLayer, item)
+	w.DrawScreenList = removeObject(w.DrawScreenList, item)
+	w.HitAbleList = removeObject(w.HitAbleList, item)
+	w.UpdateList = removeObject(w.UpdateList, item)
+	w.CollideList = removeObject(w.CollideList, item)
+	w.hitHash.Remove(item)
+	if w.contacts != nil {
+		w.contacts.Remove(item)
+	}
+}
+
+// removeObject returns the list without the object, keeping the order
+func removeObject(list []com.GameObject, o com.GameObject) []com.GameObject {
+	for i, l := range list {
+		if l == o {
+			return append(list[:i:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+func contains(handles []Handle, h Handle) bool {
+	for _, d := range handles {
+		if d == h {
+			return true
+		}
+	}
+	return false
+}
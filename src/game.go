@@ -1,120 +1,60 @@
 package src
 
 import (
+	"fmt"
+	"path/filepath"
+	"time"
+
 	gui "moonlander/src/gui"
 	sha "moonlander/src/shared"
 
 	"github.com/hajimehoshi/ebiten"
-	"golang.org/x/image/math/f64"
 )
 
-// Game implements ebiten.Game interface.
+// Game implements ebiten.Game interface, it runs the scenes on its stack
 type Game struct {
-	mode   int
-	world  *ebiten.Image
-	camera Camera
+	scenes     []Scene
+	transition Transition
+	change     func()
 }
 
-// Mode values (0,1,2)
-const (
-	ModeTitle int = iota
-	ModeGame
-	ModeGameOver
-)
-
-var (
-	g *Game
-)
-
 // Run this code once at startup app
 func init() {
-	gui.InitTitle()
+	records = LoadRecords(recordsPath())
+	ScanLevels()
 }
 
-// Update proceeds the game state.
-func (g *Game) Update(screen *ebiten.Image) error {
-	var action string
-
-	switch g.mode {
-	case ModeTitle:
-		action = gui.UpdateTitle(screen)
-		if action != "" {
-			g.mode = ModeGame
-			loadState(g, action)
-		}
-
-	case ModeGame:
-		// loop through update list and collide list
-		for _, i := range UpdateList {
-			i.Update(screen)
-		}
-		for _, i := range CollideList {
-			i.Collide(HitAbleList)
-		}
+// initTitle inits the title screen with the level catalogue and the best times
+func initTitle() {
+	gui.InitTitle(ScanLevels(), records.Summaries())
+}
 
-		// test game-over screen
-		if ebiten.IsKeyPressed(ebiten.KeyBackslash) {
-			g.mode = ModeGameOver
-			loadState(g, "")
+// Update proceeds the game state, the top scene is updated (scenes wait while a transition runs)
+func (g *Game) Update(screen *ebiten.Image) error {
+	if g.transition != nil {
+		change, done := g.transition.Update()
+		if change && g.change != nil {
+			g.change()
+			g.change = nil
 		}
-
-		// update camera
-		g.camera.Update()
-
-	case ModeGameOver:
-		action = gui.UpdateGameOver(screen)
-		if action != "" {
-			g.mode = ModeTitle
-			loadState(g, action)
+		if done {
+			g.transition = nil
 		}
+		return nil
 	}
-
-	// handle escape in game or gameover screen
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		if g.mode == ModeGame || g.mode == ModeGameOver {
-			g.mode = ModeTitle
-			loadState(g, "")
-		}
+	if len(g.scenes) == 0 {
+		return nil
 	}
-	return nil
+	return g.scenes[len(g.scenes)-1].Update(g, screen)
 }
 
-// Draw draws the game screen.
+// Draw draws the game screen, all scenes bottom first, and the transition on top
 func (g *Game) Draw(screen *ebiten.Image) {
-	switch g.mode {
-	case ModeTitle:
-		gui.DrawTitle(screen)
-	case ModeGame:
-		// draw in world
-		for _, i := range DrawWorldList {
-			i.Draw(g.world)
-		}
-		// render world in camera
-		g.camera.Render(g.world, screen)
-
-		// draw on screen (gui)
-		for _, i := range DrawScreenList {
-			i.Draw(screen)
-		}
-	case ModeGameOver:
-		gui.DrawGameOver(screen)
+	for _, s := range g.scenes {
+		s.Draw(screen)
 	}
-}
-
-// Load different modes of the game
-func loadState(g *Game, action string) {
-	if g.mode == ModeTitle {
-		ClearLevel()
-		gui.InitTitle()
-
-	} else if g.mode == ModeGame {
-		gui.ClearTitle()
-		LoadLevel(action)
-		g.camera.Reset()
-
-	} else if g.mode == ModeGameOver {
-		ClearLevel()
-		gui.InitGameOver()
+	if g.transition != nil {
+		g.transition.Draw(screen)
 	}
 }
 
@@ -124,14 +64,26 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return sha.ScreenWidth, sha.ScreenHeight
 }
 
-// Start the game
-func Start() {
+// Start the game, with a replay path it starts playing that replay
+func Start(replayPath string) {
 	ebiten.SetWindowSize(sha.ScreenWidth, sha.ScreenHeight)
 	ebiten.SetWindowTitle("Moon Lander!!")
-
-	// set camera
-	g = &Game{}
-	g.camera = Camera{ViewPort: f64.Vec2{sha.ScreenWidth, sha.ScreenHeight}}
+	// every Update is one tick of the simulation clock
+	ebiten.SetMaxTPS(sha.TPS)
+	// keep updating without focus, so a level can pause itself
+	ebiten.SetRunnableOnUnfocused(true)
+
+	g := &Game{}
+	g.Switch(&TitleScene{}, nil)
+	if replayPath != "" {
+		world, err := loadReplay(replayPath)
+		if err != nil {
+			fmt.Println("replay failed to load:", err)
+			g.Switch(&TitleScene{message: "replay failed to load\n" + err.Error()}, nil)
+		} else {
+			g.Switch(NewLevelScene(world), nil)
+		}
+	}
 
 	// Rungame starts main loop
 	if err := ebiten.RunGame(g); err != nil {
@@ -139,8 +91,57 @@ func Start() {
 	}
 }
 
-// SetWorldImage sets the size of world canvas image (should be same as level size)
-// should be called when changing level
-func SetWorldImage(width, height int) {
-	g.world, _ = ebiten.NewImage(width, height, ebiten.FilterDefault)
+// loadReplay loads a replay file in a new World
+func loadReplay(path string) (*World, error) {
+	r, err := LoadReplay(path)
+	if err != nil {
+		return nil, err
+	}
+	return PlayReplay(r)
+}
+
+// submitRecords adds the times of a finished race to the records, and shows the leaderboard.
+// A run which sets a record keeps its replay, played back replays set no records.
+// It returns the places of the new lap and total records, 0 when there is none
+func submitRecords(w *World) (int, int) {
+	id, laps := w.Info.ID, w.LP.LapTicks
+	r := w.Replay()
+	if r == nil || len(laps) == 0 || !records.Qualifies(id, laps) {
+		w.SetLeaderboard(records.Leaderboard(id))
+		return 0, 0
+	}
+	rec := Record{Name: playerName(), Date: time.Now()}
+	path := filepath.Join(replayDir, fmt.Sprintf("%v-%v.mlr", id, rec.Date.Format("20060102-150405")))
+	if err := r.Save(path); err != nil {
+		fmt.Printf("warning: replay not saved: %v\n", err)
+	} else {
+		rec.Replay = path
+	}
+	lapPlace, totalPlace := records.Add(id, laps, w.LP.LapSplits, rec)
+	if err := records.Save(); err != nil {
+		fmt.Printf("warning: records not saved: %v\n", err)
+	}
+
+	board := records.Leaderboard(id)
+	if lapPlace > 0 {
+		board += fmt.Sprintf("\nNEW LAP RECORD #%v", lapPlace)
+	}
+	if totalPlace > 0 {
+		board += fmt.Sprintf("\nNEW TOTAL RECORD #%v", totalPlace)
+	}
+	w.SetLeaderboard(board)
+	return lapPlace, totalPlace
+}
+
+// raceResult is the result of a finished race, for the result screen
+func raceResult(w *World, lapPlace, totalPlace int) gui.Result {
+	r := gui.Result{Title: w.Info.Title, LapRecord: lapPlace, TotalRecord: totalPlace}
+	for _, t := range w.LP.LapTicks {
+		r.LapTimes = append(r.LapTimes, sha.FormatTicks(t))
+	}
+	total := totalTicks(w.LP.LapTicks)
+	r.Total = sha.FormatTicks(total)
+	r.Medal = w.Info.Medal(total)
+	_, r.HasNext = nextLevel(w.Info.ID)
+	return r
 }
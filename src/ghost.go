@@ -0,0 +1,181 @@
+package src
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	com "moonlander/src/components"
+	sha "moonlander/src/shared"
+)
+
+// ghost file header, followed by the version of the format
+const (
+	ghostMagic   = "MLGH"
+	ghostVersion = 1
+)
+
+// BestLap is the best lap flown in a level, as a trace of a frame per tick, the ghost flies it
+type BestLap struct {
+	Ticks int
+	Trace []com.GhostFrame
+}
+
+// a frame as stored in a ghost file
+type ghostFrame struct {
+	X, Y, R  float32
+	Controls uint8
+}
+
+// Write writes the best lap: the header, the ticks and the number of frames, and the frames
+func (b *BestLap) Write(wr io.Writer) error {
+	bw := bufio.NewWriter(wr)
+	bw.WriteString(ghostMagic)
+	bw.WriteByte(ghostVersion)
+	binary.Write(bw, binary.LittleEndian, [2]uint32{uint32(b.Ticks), uint32(len(b.Trace))})
+	for _, f := range b.Trace {
+		binary.Write(bw, binary.LittleEndian, ghostFrame{float32(f.X), float32(f.Y), float32(f.R), f.Controls})
+	}
+	return bw.Flush()
+}
+
+// ReadBestLap reads a best lap written by Write
+func ReadBestLap(rd io.Reader) (*BestLap, error) {
+	br := bufio.NewReader(rd)
+	head := make([]byte, len(ghostMagic)+1)
+	if _, err := io.ReadFull(br, head); err != nil {
+		return nil, fmt.Errorf("ghost header: %v", err)
+	}
+	if string(head[:len(ghostMagic)]) != ghostMagic {
+		return nil, fmt.Errorf("not a ghost file")
+	}
+	if head[len(ghostMagic)] != ghostVersion {
+		return nil, fmt.Errorf("unsupported ghost version %v", head[len(ghostMagic)])
+	}
+	var size [2]uint32
+	if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+		return nil, fmt.Errorf("ghost size: %v", err)
+	}
+	b := &BestLap{Ticks: int(size[0])}
+	for i := uint32(0); i < size[1]; i++ {
+		var f ghostFrame
+		if err := binary.Read(br, binary.LittleEndian, &f); err != nil {
+			return nil, fmt.Errorf("ghost frame %v: %v", i, err)
+		}
+		b.Trace = append(b.Trace, com.GhostFrame{X: float64(f.X), Y: float64(f.Y), R: float64(f.R), Controls: f.Controls})
+	}
+	return b, nil
+}
+
+// ghostPath is the file of the best lap of a level
+func ghostPath(level string) string {
+	return filepath.Join(replayDir, level+".ghost")
+}
+
+// loadBestLap reads the best lap of a level, nil when there is none yet
+func loadBestLap(level string) (*BestLap, error) {
+	f, err := os.Open(ghostPath(level))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b, err := ReadBestLap(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", ghostPath(level), err)
+	}
+	return b, nil
+}
+
+// saveBestLap writes the best lap of a level
+func saveBestLap(level string, b *BestLap) error {
+	path := ghostPath(level)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := b.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadGhost adds the ghost of the best lap of the level to the world, when there is one
+func (w *World) loadGhost() {
+	b, err := loadBestLap(w.Info.ID)
+	if err != nil {
+		fmt.Printf("warning: best lap not loaded: %v\n", err)
+		return
+	}
+	if b != nil {
+		w.bestLap = b
+		w.setGhost(b.Trace)
+	}
+}
+
+// setGhost lets the ghost fly a trace, the ghost is spawned the first time
+func (w *World) setGhost(trace []com.GhostFrame) {
+	if w.ghost != nil {
+		w.ghost.SetTrace(trace)
+		return
+	}
+	g, err := com.NewGhost(trace, &w.LP)
+	if err != nil {
+		fmt.Printf("warning: ghost not created: %v\n", err)
+		return
+	}
+	w.ghost = &g
+	w.ghost.SetHidden(w.hideGhost)
+	p := sha.Props{}
+	p.Set("draw", "bool", "true")
+	p.Set("update", "bool", "true")
+	w.SpawnObject(w.ghost, p)
+}
+
+// ShowGhost shows or hides the ghost
+func (w *World) ShowGhost(show bool) {
+	w.hideGhost = !show
+	if w.ghost != nil {
+		w.ghost.SetHidden(w.hideGhost)
+	}
+}
+
+// recordLap traces the player every tick of a lap, a finished lap which is faster than the best lap
+// becomes the best lap, it is saved and the ghost flies it from the next lap.
+// The splits of a lap faster than the best splits become the splits to beat
+func (w *World) recordLap() {
+	if w.player == nil || !w.LP.LapStarted {
+		return
+	}
+	if n := len(w.LP.LapTicks); n > w.laps {
+		w.laps = n
+		ticks := w.LP.LapTicks[n-1]
+		// a faster lap has the splits to beat
+		if (w.splitTicks == 0 || ticks < w.splitTicks) && n <= len(w.LP.LapSplits) {
+			w.SetBestSplits(ticks, w.LP.LapSplits[n-1])
+		}
+		if w.bestLap == nil || ticks < w.bestLap.Ticks {
+			w.bestLap = &BestLap{Ticks: ticks, Trace: w.lapTrace}
+			// only laps flown now are saved, not laps of a replay being played (which has no recording)
+			if w.replay != nil {
+				if err := saveBestLap(w.Info.ID, w.bestLap); err != nil {
+					fmt.Printf("warning: best lap not saved: %v\n", err)
+				}
+			}
+			w.setGhost(w.bestLap.Trace)
+		}
+		w.lapTrace = nil
+	}
+	if w.LP.CurrentLap <= w.LP.MaxLaps {
+		w.lapTrace = append(w.lapTrace, w.player.GhostFrame())
+	}
+}
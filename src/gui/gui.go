@@ -7,6 +7,7 @@ import (
 	"github.com/golang/freetype/truetype"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/examples/resources/fonts"
+	"github.com/hajimehoshi/ebiten/inpututil"
 	"github.com/hajimehoshi/ebiten/text"
 	"golang.org/x/image/font"
 )
@@ -32,7 +33,7 @@ type clickable interface {
 }
 
 type button struct {
-	name, text         string
+	name, text, info   string
 	x, y, w, h         int
 	active             bool
 	img                *ebiten.Image
@@ -115,6 +116,10 @@ func (b *button) draw(screen *ebiten.Image) {
 	textX := (b.w - textWidth) / 2
 	textY := (b.h + textHeight) / 2
 	text.Draw(screen, b.text, fontNormal, b.x+textX, b.y+textY, b.txtColor)
+	// draw optional info below the button
+	if b.info != "" {
+		text.Draw(screen, b.info, fontArcade, b.x, b.y+b.h+20, color.White)
+	}
 }
 
 // Check hits on group of buttons, if hit set the button active and all other in the group as inactive
@@ -136,7 +141,8 @@ func checkHits(x, y int, clickables []clickable) clickable {
 func (p *pointer) update() {
 	p.pressed = false
 	p.x, p.y = ebiten.CursorPosition()
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+	// only react on the press itself, so a hold doesn't click through to the next screen
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		p.pressed = true
 	}
 }
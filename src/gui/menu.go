@@ -0,0 +1,62 @@
+package gui
+
+import (
+	"image/color"
+
+	sha "moonlander/src/shared"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/text"
+)
+
+// MenuItem is a button of a Menu, the name is returned when it is clicked
+type MenuItem struct {
+	Name, Text string
+}
+
+// Menu is an overlay with a column of buttons, drawn over the screen below it (like a paused level)
+type Menu struct {
+	title   string
+	btnList []clickable
+	dim     *ebiten.Image
+}
+
+// NewMenu constructor
+func NewMenu(title string, items []MenuItem) *Menu {
+	dim, _ := ebiten.NewImage(sha.ScreenWidth, sha.ScreenHeight, ebiten.FilterDefault)
+	dim.Fill(color.RGBA{0, 0, 0, 160})
+	m := &Menu{title: title, dim: dim}
+	w, h := 300, 60
+	y := sha.ScreenHeight/2 - len(items)*(h+20)/2
+	btnColor := color.RGBA{0, 255, 0, 128}
+	txtColor := color.RGBA{0, 0, 0, 128}
+	for i, item := range items {
+		btn := newButton(item.Name, item.Text, sha.ScreenWidth/2-w/2, y+i*(h+20), w, h, fontNormal, btnColor, txtColor)
+		m.btnList = append(m.btnList, &btn)
+	}
+	return m
+}
+
+// Update returns the name of the clicked item
+func (m *Menu) Update(screen *ebiten.Image) string {
+	mouse.update()
+	if mouse.pressed {
+		if btn := checkHits(mouse.x, mouse.y, m.btnList); btn != nil {
+			// a menu button can be clicked again
+			btn.setActive(true)
+			return btn.getName()
+		}
+	}
+	return ""
+}
+
+// Draw dims the screen and draws the menu on it
+func (m *Menu) Draw(screen *ebiten.Image) {
+	screen.DrawImage(m.dim, &ebiten.DrawImageOptions{})
+	bounds := text.BoundString(fontBig, m.title)
+	y := sha.ScreenHeight/2 - len(m.btnList)*40 - 60
+	text.Draw(screen, m.title, fontBig, (sha.ScreenWidth-bounds.Dx())/2, y, color.White)
+	for _, btn := range m.btnList {
+		btn.draw(screen)
+	}
+}
@@ -0,0 +1,107 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+
+	sha "moonlander/src/shared"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/text"
+)
+
+// Result is the result of a race, as shown on the result screen
+type Result struct {
+	Title    string
+	LapTimes []string
+	Total    string
+	// gold, silver, bronze or empty
+	Medal string
+	// places of the new lap and total records, 0 when there is no record
+	LapRecord, TotalRecord int
+	// there is a next level
+	HasNext bool
+}
+
+// actions of the result screen buttons
+const (
+	ActionRetry = "retry"
+	ActionNext  = "next"
+	ActionMenu  = "menu"
+)
+
+var (
+	result        Result
+	resultBtnList []clickable
+)
+
+// colors of the medals
+var medalColors = map[string]color.RGBA{
+	"gold":   {255, 215, 0, 255},
+	"silver": {192, 192, 192, 255},
+	"bronze": {205, 127, 50, 255},
+}
+
+// ClearResult clears the result screen
+func ClearResult() {
+	resultBtnList = nil
+}
+
+// InitResult inits the result screen of a race, with Retry, Next Level (when there is one) and Menu buttons
+func InitResult(r Result) {
+	result = r
+	resultBtnList = nil
+	w, h := 250, 80
+	y := sha.ScreenHeight - 200
+	btnColor := color.RGBA{0, 255, 0, 128}
+	txtColor := color.RGBA{0, 0, 0, 128}
+	buttons := []struct{ name, text string }{{ActionRetry, "Retry"}, {ActionNext, "Next Level"}, {ActionMenu, "Menu"}}
+	for i, b := range buttons {
+		if b.name == ActionNext && !r.HasNext {
+			continue
+		}
+		btn := newButton(b.name, b.text, sha.ScreenWidth/4*(i+1)-w/2, y, w, h, fontNormal, btnColor, txtColor)
+		resultBtnList = append(resultBtnList, &btn)
+	}
+}
+
+// UpdateResult returns the action of the clicked button
+func UpdateResult(screen *ebiten.Image) string {
+	mouse.update()
+	if mouse.pressed {
+		if btn := checkHits(mouse.x, mouse.y, resultBtnList); btn != nil {
+			return btn.getName()
+		}
+	}
+	return ""
+}
+
+// DrawResult draws the result screen
+func DrawResult(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{0x20, 0x30, 0x50, 0xff})
+	text.Draw(screen, result.Title, fontBig, 200, 140, color.White)
+
+	// lap times and total
+	y := 240
+	for i, lt := range result.LapTimes {
+		text.Draw(screen, fmt.Sprintf("LAP %d   %v", i+1, lt), fontArcade, 200, y, color.White)
+		y += 30
+	}
+	text.Draw(screen, "TOTAL   "+result.Total, fontArcade, 200, y+10, color.White)
+
+	if c, ok := medalColors[result.Medal]; ok {
+		text.Draw(screen, result.Medal+" medal", fontBig, 700, 280, c)
+	}
+	records := ""
+	if result.LapRecord > 0 {
+		records += fmt.Sprintf("NEW LAP RECORD #%d\n", result.LapRecord)
+	}
+	if result.TotalRecord > 0 {
+		records += fmt.Sprintf("NEW TOTAL RECORD #%d\n", result.TotalRecord)
+	}
+	text.Draw(screen, records, fontArcade, 700, 360, color.RGBA{255, 255, 0, 255})
+
+	for _, btn := range resultBtnList {
+		btn.draw(screen)
+	}
+}
@@ -16,25 +16,49 @@ const (
 var (
 	btnList    []clickable
 	clickedBtn clickable
+	message    string
 )
 
 // ClearTitle clears the title screen
 func ClearTitle() {
 	btnList = nil
 	clickedBtn = nil
+	message = ""
 }
 
-// InitTitle inits the title screen
-func InitTitle() {
-	// create some buttons
+// SetTitleMessage shows a message (e.g. an error) on the title screen, until it is cleared
+func SetTitleMessage(msg string) {
+	message = msg
+}
+
+// InitTitle inits the title screen, with a button for each level in the catalogue (3 per row),
+// records are the best times of the levels, by level id
+func InitTitle(levels []sha.LevelInfo, records map[string]string) {
 	w, h := 250, 100
-	x1, x2, x3, y := sha.ScreenWidth/4-w/2, sha.ScreenWidth/2-w/2, sha.ScreenWidth/4*3-w/2, sha.ScreenHeight/3-h/2
+	xs := []int{sha.ScreenWidth/4 - w/2, sha.ScreenWidth/2 - w/2, sha.ScreenWidth/4*3 - w/2}
+	y := sha.ScreenHeight/3 - h/2
 	btnColor := color.RGBA{0, 255, 0, 128}
 	txtColor := color.RGBA{0, 0, 0, 128}
-	btn1 := newButton("lvl01", "Level 1 Amazing!!", x1, y, w, h, fontNormal, btnColor, txtColor)
-	btn2 := newButton("lvl02", "Level 2", x2, y, w, h, fontNormal, btnColor, txtColor)
-	btn3 := newButton("lvl03", "Level 3", x3, y, w, h, fontNormal, btnColor, txtColor)
-	btnList = append(btnList, &btn1, &btn2, &btn3)
+	for i, l := range levels {
+		btn := newButton(l.ID, l.Title, xs[i%len(xs)], y+(i/len(xs))*(h+80), w, h, fontNormal, btnColor, txtColor)
+		btn.info = levelInfoText(l)
+		if r, ok := records[l.ID]; ok {
+			btn.info += "\n" + r
+		}
+		btnList = append(btnList, &btn)
+	}
+}
+
+// levelInfoText is shown below a level button
+func levelInfoText(l sha.LevelInfo) string {
+	info := l.Difficulty
+	if l.Author != "" {
+		if info != "" {
+			info += " - "
+		}
+		info += "by " + l.Author
+	}
+	return info
 }
 
 // UpdateTitle ..
@@ -61,7 +85,7 @@ func DrawTitle(screen *ebiten.Image) {
 	text.Draw(screen, sampleText, fontNormal, 20, 120, color.White)
 
 	text.Draw(screen, ""+
-		"main menu     = esc \n\n"+
+		"pause         = esc \n\n"+
 		"Move up       = up\n"+
 		"Move right    = right\n"+
 		"Move left     = left\n"+
@@ -81,4 +105,9 @@ func DrawTitle(screen *ebiten.Image) {
 		btn.draw(screen)
 	}
 
+	// draw message below the buttons
+	if message != "" {
+		text.Draw(screen, message, fontNormal, 200, sha.ScreenHeight/3+100, color.RGBA{255, 64, 64, 255})
+	}
+
 }
@@ -3,14 +3,18 @@ package src
 import (
 	"encoding/xml"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strconv"
 
 	com "moonlander/src/components"
 	sha "moonlander/src/shared"
 
+	"github.com/hajimehoshi/ebiten"
 	"github.com/lafriks/go-tiled"
 )
 
@@ -35,56 +39,74 @@ type Property struct {
 	Default string `xml:"default,attr"`
 }
 
-// Variables related to level
-var (
-	player         com.Player
-	DrawWorldList  []com.GameObject
-	DrawScreenList []com.GameObject
-	HitAbleList    []com.GameObject
-	UpdateList     []com.GameObject
-	CollideList    []com.GameObject
-	checkpoints    []*com.Checkpoint
-	finish         com.Finish
-)
+// LevelError describes why a level failed to load,
+// ObjectID and Property are only set when the error is caused by a specific object or property
+type LevelError struct {
+	File     string
+	ObjectID int
+	Property string
+	Cause    error
+}
+
+func (e *LevelError) Error() string {
+	s := e.File
+	if e.ObjectID != 0 {
+		s += fmt.Sprintf(", object %d", e.ObjectID)
+	}
+	if e.Property != "" {
+		s += fmt.Sprintf(", property %q", e.Property)
+	}
+	return s + ": " + e.Cause.Error()
+}
 
-// ClearLevel global variables
-func ClearLevel() {
-	DrawWorldList = nil
-	DrawScreenList = nil
-	HitAbleList = nil
-	UpdateList = nil
-	CollideList = nil
-	checkpoints = nil
+// Unwrap returns the cause of the error
+func (e *LevelError) Unwrap() error {
+	return e.Cause
 }
 
-// LoadLevel loads a specific level
-func LoadLevel(name string) {
+// size of the broadphase cells, about the size of the player
+const hitHashCell = 64
+
+// tries to find a free position for a spawned entity, before it is skipped
+const maxSpawnTries = 100
+
+// LoadLevel loads a level from the level catalogue in a new World, seeded with seed.
+// On error a *LevelError is returned
+func LoadLevel(id string, seed int64) (*World, error) {
 	// xml created by Tiled with default values of object types
 	// as long as the default values are not overriden, they will not be in TMX file
-	objectTypePath := "assets/tiled/objecttypes.xml"
-	if name == "lvl01" {
-		loadTiledData("assets/tiled/level01.tmx", objectTypePath)
-		finalizeLevel()
-	} else if name == "lvl02" {
-		loadTiledData("assets/tiled/level02.tmx", objectTypePath)
-		finalizeLevel()
-		spwanRandomSquares(HitAbleList, 8, 50)
-	} else if name == "lvl03" {
-		loadTiledData("assets/tiled/level03.tmx", objectTypePath)
-		finalizeLevel()
+	objectTypePath := filepath.Join(levelDir, "objecttypes.xml")
+	info, ok := getLevel(id)
+	if !ok {
+		return nil, &LevelError{File: id, Cause: fmt.Errorf("level not in catalogue")}
+	}
+	w := NewWorld(info, seed)
+	if err := w.loadTiledData(info.Path, objectTypePath); err != nil {
+		return nil, err
 	}
+	w.finalize()
+	w.loadGhost()
+
+	// record the run, so it can be saved as a replay
+	w.replay = &Replay{Level: id, Seed: seed}
+	if w.player != nil {
+		w.player.SetInput(&recordInput{in: com.KeyboardInput{}, replay: w.replay})
+	}
+	return w, nil
 }
 
 // Handles Tiled data
-func loadTiledData(mapPath string, objectpath string) {
+func (w *World) loadTiledData(mapPath string, objectpath string) error {
 	m, err := tiled.LoadFromFile(mapPath)
 	if err != nil {
-		fmt.Printf("error parsing map: %s", err.Error())
-		os.Exit(2)
+		return &LevelError{File: mapPath, Cause: err}
+	}
+	if m.Properties == nil {
+		m.Properties = &tiled.Properties{}
 	}
 
 	// set Level properties from tmx map properties
-	sha.LP = sha.LevelProperties{
+	w.LP = sha.LevelProperties{
 		Gravity:  getLevelGravity(m),
 		Friction: getLevelFriction(m),
 		MaxLaps:  getLevelMaxMaps(m),
@@ -92,142 +114,332 @@ func loadTiledData(mapPath string, objectpath string) {
 		Height:   m.Height * m.TileHeight,
 		BG:       getLevelBackground(m),
 	}
-	bg := com.NewBackground(sha.IDBG, sha.LP.BG, 0, 0, 0, sha.LP.Width, sha.LP.Height, com.Vector{})
-	DrawWorldList = append(DrawWorldList, &bg)
-	fmt.Printf("\n\nLevel: %v\nProperties:%+v\n\n", mapPath, sha.LP)
+	bg, err := com.NewBackground(sha.IDBG, w.LP.BG, 0, 0, com.ZBackground, w.LP.Width, w.LP.Height, com.Vector{})
+	if err != nil {
+		return &LevelError{File: mapPath, Property: "background", Cause: err}
+	}
+	w.addDrawable(&bg, 0)
+	w.hitHash = com.NewSpatialHash(w.LP.Width, w.LP.Height, hitHashCell)
+	fmt.Printf("\n\nLevel: %v\nProperties:%+v\n\n", mapPath, w.LP)
 
 	// Get object types properties default values
-	objectTypes := getObjectTypes(objectpath)
+	objectTypes, err := getObjectTypes(objectpath)
+	if err != nil {
+		return &LevelError{File: objectpath, Cause: err}
+	}
+	w.objectTypes = objectTypes
+
+	// the order of the layers in Tiled, objects in the same z are drawn in this order
+	order, err := getLayerOrder(mapPath)
+	if err != nil {
+		return &LevelError{File: mapPath, Cause: err}
+	}
 
-	//loop through tile layers (not used atm)
+	// loop through tile layers
+	images := tileImages{}
 	for _, layer := range m.Layers {
-		for i, tile := range layer.Tiles {
-			x, y := layer.GetTilePosition(i)
-			if tile.Tileset != nil {
-				id := strconv.FormatUint(uint64(tile.ID), 10)
-				addLevelItem(id, "", x, y, m.TileWidth, m.TileHeight, 0,
-					tile.Tileset.Tiles[tile.ID].Properties, objectTypes)
-			}
+		w.loadLayer = order[layer.ID]
+		if err := w.loadTileLayer(m, layer, objectTypes, images, mapPath); err != nil {
+			return err
 		}
 	}
 	// loop through object layers
 	for _, objLayer := range m.ObjectGroups {
+		w.loadLayer = order[objLayer.ID]
+		layerProps := getItemProps("", nil, objLayer.Properties, nil)
 		for _, obj := range objLayer.Objects {
-			addLevelItem(obj.Type, obj.Name, int(obj.X), int(obj.Y), int(obj.Width),
-				int(obj.Height), int(obj.Rotation), obj.Properties, objectTypes)
+			// tile objects inherit type and properties from the tileset tile
+			typ, tileProps := obj.Type, tiled.Properties(nil)
+			if obj.GID != 0 {
+				if tile, err := m.TileGIDToTile(obj.GID); err == nil {
+					tileProps = getTileProps(tile)
+					if typ == "" {
+						typ = getTileType(tile)
+					}
+				}
+			}
+			// polygons and polylines are terrain, unless they have a type
+			points, closed := getObjectPoints(obj)
+			if typ == "" && points != nil {
+				typ = "terrain"
+			}
+			p := getItemProps(typ, tileProps, obj.Properties, objectTypes)
+			// objects without their own z get the z of their layer
+			if !p.Has("z") && layerProps.Has("z") {
+				p.Set("z", "int", layerProps.GetString("z", ""))
+			}
+			err := w.addLevelItem(com.ItemArgs{
+				ID: int(obj.ID), Name: obj.Name,
+				X: int(obj.X), Y: int(obj.Y), W: int(obj.Width), H: int(obj.Height),
+				Rotation: obj.Rotation, Props: p,
+				Points: points, Closed: closed,
+			}, typ, mapPath)
+			if err != nil {
+				return err
+			}
 		}
 	}
+	w.applyQueues()
+
+	// objects of spawners, and objects spawned while playing, are drawn over the Tiled layers in their z
+	w.loadLayer = len(order)
+	// populate spawner regions, when all static objects are known
+	for _, s := range w.spawners {
+		if err := w.spawnItems(s, objectTypes, mapPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the points of a polygon or polyline object in world coordinates (rotated around the object position),
+// closed is true for a polygon
+func getObjectPoints(obj *tiled.Object) ([]com.Vector, bool) {
+	var tp *tiled.Points
+	closed := false
+	if len(obj.Polygons) > 0 {
+		tp, closed = obj.Polygons[0].Points, true
+	} else if len(obj.PolyLines) > 0 {
+		tp = obj.PolyLines[0].Points
+	}
+	if tp == nil {
+		return nil, false
+	}
+	rad := obj.Rotation * math.Pi / 180
+	points := make([]com.Vector, 0, len(*tp))
+	for _, pt := range *tp {
+		x, y := com.GetRotatedPoint(obj.X, obj.Y, pt.X, pt.Y, rad)
+		points = append(points, com.NewVector(x, y))
+	}
+	return points, closed
+}
+
+// Get the properties of a tile, as defined in the tileset
+func getTileProps(tile *tiled.LayerTile) tiled.Properties {
+	if t := getTilesetTile(tile); t != nil {
+		return t.Properties
+	}
+	return nil
+}
+
+// Get the object type of a tile, as defined in the tileset
+func getTileType(tile *tiled.LayerTile) string {
+	if t := getTilesetTile(tile); t != nil {
+		return t.Type
+	}
+	return ""
+}
+
+// Find the tileset definition of a tile, tilesets only list tiles with extra info
+func getTilesetTile(tile *tiled.LayerTile) *tiled.TilesetTile {
+	if tile == nil || tile.Tileset == nil {
+		return nil
+	}
+	for _, t := range tile.Tileset.Tiles {
+		if t.ID == tile.ID {
+			return t
+		}
+	}
+	return nil
 }
 
 // Tiled object types xml to Structs
-func getObjectTypes(xmlPath string) []ObjectType {
+func getObjectTypes(xmlPath string) ([]ObjectType, error) {
 	xmlFile, err := os.Open(xmlPath)
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
 	}
 	defer xmlFile.Close()
 
 	// read xmlFile as a byte array and unmarshall to struct
-	data, _ := ioutil.ReadAll(xmlFile)
+	data, err := ioutil.ReadAll(xmlFile)
+	if err != nil {
+		return nil, err
+	}
 	var objectTypes ObjectTypes
-	xml.Unmarshal(data, &objectTypes)
-	return objectTypes.ObjectType
+	if err := xml.Unmarshal(data, &objectTypes); err != nil {
+		return nil, err
+	}
+	return objectTypes.ObjectType, nil
 }
 
-// Factory for populating the level with GameObjects
-func addLevelItem(itemType, name string, x, y, w, h, rotation int, props tiled.Properties, objectTypes []ObjectType) {
-	// fmt.Printf("id:%v, name:%v, x:%v ,y:%v, w:%v, h:%v, r:%v, prop:%v", id, name, x, y, w, h, rotation, prop)
-	// get item properties
-	p := getItemProps(itemType, props, objectTypes)
-	switch itemType {
-	case "wall":
-		o := com.NewWall(sha.IDWall, x, y, w, h, sha.Blue50)
-		addItemToList(&o, p)
-		break
-	case "player":
-		player = com.NewPlayer(sha.IDPlayer, x, y, 0, com.Vector{}, 8, 8, 30, 48, sha.Red50)
-		addItemToList(&player, p)
-		break
-	case "tester":
-		o := com.NewCollideTest(sha.IDTester, x, y, 0, com.Vector{}, 4, 4, 24, 56, sha.Green50)
-		addItemToList(&o, p)
-		break
-	case "cp":
-		o := com.NewCheckpoint(sha.IDCheckpoint, x, y, w, h, sha.Cyan25, true)
-		checkpoints = append(checkpoints, &o)
-		addItemToList(&o, p)
-		break
-	case "finish":
-		finish = com.NewFinish(sha.IDFinish, x, y, w, h, sha.White25, nil)
-		addItemToList(&finish, p)
-		break
+// Factory for populating the level with GameObjects, using the constructors registered by the components
+// unknown types are skipped with a warning, invalid properties and constructor errors are returned
+func (w *World) addLevelItem(a com.ItemArgs, itemType, mapPath string) error {
+	a.Level = &w.LP
+	if name, err := a.Props.Check(); err != nil {
+		return &LevelError{File: mapPath, ObjectID: a.ID, Property: name, Cause: err}
+	}
+	o, err := com.Construct(itemType, a)
+	if err == com.ErrUnknownType {
+		fmt.Printf("warning: %v: object %v (%q) has unknown type %q, skipped\n", mapPath, a.ID, a.Name, itemType)
+		return nil
+	}
+	if err != nil {
+		return &LevelError{File: mapPath, ObjectID: a.ID, Cause: err}
+	}
+	if name, err := setItemLayers(o, a.Props); err != nil {
+		return &LevelError{File: mapPath, ObjectID: a.ID, Property: name, Cause: err}
 	}
+	setItemZ(o, a.Props)
+	w.SpawnObject(o, a.Props)
+	return nil
 }
 
-// Get the default, unique and overridden properties
-func getItemProps(typ string, props tiled.Properties, objectTypes []ObjectType) []Property {
-	// match id, with objectType.Name, if it matches return Properties
-	for _, o := range objectTypes {
-		if o.Name == typ {
-			return o.Properties
+// Set the collision layer and mask of an item from its properties, when they are set,
+// returns the name of an invalid property
+func setItemLayers(o com.GameObject, p sha.Props) (string, error) {
+	obj := o.GetObject()
+	if obj == nil {
+		// level data like spawners doesn't collide
+		return "", nil
+	}
+	layer, mask := obj.GetLayers()
+	var err error
+	if p.Has("layer") {
+		if layer, err = com.ParseLayers(p.GetString("layer", "")); err != nil {
+			return "layer", err
 		}
 	}
-	// TODO should check if values in props override the default value
-	return nil
+	if p.Has("mask") {
+		if mask, err = com.ParseLayers(p.GetString("mask", "")); err != nil {
+			return "mask", err
+		}
+	}
+	obj.SetLayers(layer, mask)
+	return "", nil
+}
+
+// Set the draw layer of an item from its z property, when it is set
+func setItemZ(o com.GameObject, p sha.Props) {
+	if z, ok := o.(com.ZOrdered); ok && p.Has("z") {
+		z.SetZ(p.GetInt("z", 0))
+	}
 }
 
-// Add the GameObjects to the correct lists, based on the (default) properties in Tiled
-func addItemToList(item com.GameObject, properties []Property) {
-	for _, p := range properties {
-		if p.Name == "draw" && p.Default == "1" {
-			DrawWorldList = append(DrawWorldList, item)
+// Get the order of the tile and object layers in a TMX file, by layer id, layers in groups included
+// (the layers are kept in separate lists after loading)
+func getLayerOrder(mapPath string) (map[uint32]int, error) {
+	f, err := os.Open(mapPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	order := make(map[uint32]int)
+	d := xml.NewDecoder(f)
+	// the elements the decoder is in, layers are in the map or in a group
+	var parents []string
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return order, nil
 		}
-		if p.Name == "hit" && p.Default == "1" {
-			HitAbleList = append(HitAbleList, item)
+		if err != nil {
+			return nil, err
 		}
-		if p.Name == "update" && p.Default == "1" {
-			UpdateList = append(UpdateList, item)
+		switch e := t.(type) {
+		case xml.StartElement:
+			// not the object groups of tiles (their collision shapes)
+			parent := ""
+			if len(parents) > 0 {
+				parent = parents[len(parents)-1]
+			}
+			if (parent == "map" || parent == "group") && (e.Name.Local == "layer" || e.Name.Local == "objectgroup") {
+				for _, a := range e.Attr {
+					if a.Name.Local == "id" {
+						id, _ := strconv.ParseUint(a.Value, 10, 32)
+						order[uint32(id)] = len(order)
+					}
+				}
+			}
+			parents = append(parents, e.Name.Local)
+		case xml.EndElement:
+			parents = parents[:len(parents)-1]
 		}
-		if p.Name == "collide" && p.Default == "1" {
-			CollideList = append(CollideList, item)
+	}
+}
+
+// Get the default, tileset and overridden properties of an item,
+// later sources override earlier ones: object type defaults, tileset tile, object
+func getItemProps(typ string, tileProps, props tiled.Properties, objectTypes []ObjectType) sha.Props {
+	p := sha.Props{}
+	for _, o := range objectTypes {
+		if o.Name == typ {
+			for _, d := range o.Properties {
+				p.Set(d.Name, d.Type, d.Default)
+			}
+			break
 		}
 	}
+	for _, t := range tileProps {
+		p.Set(t.Name, t.Type, t.Value)
+	}
+	for _, o := range props {
+		p.Set(o.Name, o.Type, o.Value)
+	}
+	return p
 }
 
 // Finilize level, do stuff we can only do when we have all objects or data
-func finalizeLevel() {
+func (w *World) finalize() {
 	// set world render image (same size as the level)
-	SetWorldImage(sha.LP.Width, sha.LP.Height)
+	w.image, _ = ebiten.NewImage(w.LP.Width, w.LP.Height, ebiten.FilterDefault)
+	w.camera.SetBounds(w.LP.Width, w.LP.Height)
 
 	// player init position
-	sha.LP.PlayerStartX = int(player.X)
-	sha.LP.PlayerStartY = int(player.Y)
+	if w.player != nil {
+		w.LP.PlayerStartX = int(w.player.X)
+		w.LP.PlayerStartY = int(w.player.Y)
+	}
 
 	// create gui
-	tb := com.NewTextBlock(10, 24)
-	DrawScreenList = append(DrawScreenList, &tb)
-
-	// add all checkpoints to finish
-	finish.Checkpoints = checkpoints
-	printLevelObjects()
+	tb := com.NewTextBlock(10, 24, &w.LP)
+	w.hud = &tb
+	w.DrawScreenList = append(w.DrawScreenList, &tb)
+	w.contacts = com.NewContactManager()
+	w.printObjects()
 }
 
-// Spawns squares in playable level dimensions, and makes sure that the squares dont overlap other objects
-func spwanRandomSquares(list []com.GameObject, count, size int) {
-	for i := 0; i < count; i++ {
+// Spawns the entities of a spawner in its region (or the whole level if it has no size),
+// and makes sure that the entities dont overlap solid objects
+func (w *World) spawnItems(s *com.Spawner, objectTypes []ObjectType, mapPath string) error {
+	// spawners without their own seed use the random source of the world
+	rnd := w.rand
+	if s.Seed != 0 {
+		rnd = rand.New(rand.NewSource(s.Seed))
+	}
+	rx, ry, rw, rh := s.GetRegion()
+	if rw == 0 || rh == 0 {
+		rx, ry, rw, rh = 0, 0, w.LP.Width, w.LP.Height
+	}
+	for i := 0; i < s.Count; i++ {
 		// get random free position and random velocity
-		x, y := getRandonPosition(size, size, size, list)
-		choices := []int{2, -2}
-		vx := float64(choices[rand.Int()%len(choices)])
-		vy := float64(choices[rand.Int()%len(choices)])
-		s := com.NewSquare(sha.IDSquare, x, y, 0, com.NewVector(vx, vy), 0, 0, size, size, sha.Purple50)
-		// add Square to interface lists
-		DrawWorldList = append(DrawWorldList, &s)
-		UpdateList = append(UpdateList, &s)
-		CollideList = append(CollideList, &s)
-		HitAbleList = append(HitAbleList, &s)
-		//list = append(list, &s)
+		x, y, ok := w.getRandonPosition(rnd, rx, ry, rw, rh, s.Size, s.Size, s.Size)
+		if !ok {
+			fmt.Printf("warning: %v: spawner %v found no free position for %q, skipped\n", mapPath, s.ObjectID, s.Entity)
+			continue
+		}
+		p := getItemProps(s.Entity, nil, nil, objectTypes)
+		p.Set("vx", "float", strconv.FormatFloat(getRandomVelocity(rnd, s.VMin, s.VMax), 'f', -1, 64))
+		p.Set("vy", "float", strconv.FormatFloat(getRandomVelocity(rnd, s.VMin, s.VMax), 'f', -1, 64))
+		a := com.ItemArgs{ID: s.ObjectID, Name: s.Entity, X: x, Y: y, W: s.Size, H: s.Size, Props: p}
+		if err := w.addLevelItem(a, s.Entity, mapPath); err != nil {
+			return err
+		}
+		// the next entities don't overlap this one
+		w.applyQueues()
 	}
+	return nil
+}
+
+// random speed between min and max, in a random direction
+func getRandomVelocity(rnd *rand.Rand, min, max float64) float64 {
+	v := min + rnd.Float64()*(max-min)
+	if rnd.Intn(2) == 0 {
+		return -v
+	}
+	return v
 }
 
 func getLevelIndex(x, y int, m *tiled.Map) int {
@@ -256,39 +468,25 @@ func getLevelMaxMaps(m *tiled.Map) int {
 	return m.Properties.GetInt("maxLaps")
 }
 
-func getRandonPosition(offsetX, offsetY, space int, dontOverlap []com.GameObject) (int, int) {
-	x := rand.Intn(int(sha.LP.Width)-(offsetX*2)) + offsetX
-	y := rand.Intn(int(sha.LP.Height)-(offsetY*2)) + offsetY
-	r := com.NewRect(x, y, space, space)
-	for _, o := range dontOverlap {
-		if o.GetObject().GetSolid() {
-			if com.CheckOverlap(&r, o.GetObject().GetRect()) {
-				x, y = getRandonPosition(offsetX, offsetX, space, dontOverlap)
-			}
+// Get a random position in a region where a square of space doesn't overlap anything solid,
+// gives up after maxSpawnTries
+func (w *World) getRandonPosition(rnd *rand.Rand, rx, ry, rw, rh, offsetX, offsetY, space int) (int, int, bool) {
+	for i := 0; i < maxSpawnTries; i++ {
+		x := rnd.Intn(maxInt(rw-(offsetX*2), 1)) + rx + offsetX
+		y := rnd.Intn(maxInt(rh-(offsetY*2), 1)) + ry + offsetY
+		if len(w.QueryRect(x, y, space, space, solidLayers)) == 0 {
+			return x, y, true
 		}
 	}
-	return x, y
+	return 0, 0, false
 }
 
-func printLevelObjects() {
-	fmt.Println("\nDrawWorldList")
-	for _, o := range DrawWorldList {
-		fmt.Println(o.GetInfo())
-	}
-	fmt.Println("\nDrawScreenList")
-	for _, o := range DrawScreenList {
-		fmt.Println(o.GetInfo())
-	}
-	fmt.Println("\nHitAbleList")
-	for _, o := range HitAbleList {
-		fmt.Println(o.GetInfo())
-	}
-	fmt.Println("\nUpdateList")
-	for _, o := range UpdateList {
-		fmt.Println(o.GetInfo())
-	}
-	fmt.Println("\nCollideList")
-	for _, o := range CollideList {
-		fmt.Println(o.GetInfo())
+// layers of the objects which can't be passed through
+const solidLayers = com.LayerAll &^ com.LayerTrigger
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
 	}
+	return b
 }
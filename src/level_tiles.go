@@ -0,0 +1,167 @@
+package src
+
+import (
+	"image"
+	"math"
+
+	com "moonlander/src/components"
+	sha "moonlander/src/shared"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/lafriks/go-tiled"
+)
+
+// tile properties which turn painted tiles into colliders, and the object type used for the collider
+var tileColliders = []struct{ prop, typ string }{
+	{"solid", "wall"},
+	{"hazard", "hazard"},
+}
+
+// tile images by path, so every tileset image is loaded only once per level
+type tileImages map[string]*ebiten.Image
+
+// Handles a Tiled tile layer, the tiles are drawn once in a layer image
+// and tiles with collider properties are merged to as few colliders as possible
+func (w *World) loadTileLayer(m *tiled.Map, layer *tiled.Layer, objectTypes []ObjectType, images tileImages, mapPath string) error {
+	if layer.IsEmpty() {
+		return nil
+	}
+	img, _ := ebiten.NewImage(w.LP.Width, w.LP.Height, ebiten.FilterDefault)
+	cells := make(map[string][]bool)
+	for _, c := range tileColliders {
+		cells[c.prop] = make([]bool, len(layer.Tiles))
+	}
+	for i, tile := range layer.Tiles {
+		if tile.IsNil() {
+			continue
+		}
+		x, y := layer.GetTilePosition(i)
+		tileImg, err := getTileImage(tile, images)
+		if err != nil {
+			return &LevelError{File: mapPath, Cause: err}
+		}
+		drawTile(img, tileImg, tile, x, y, m.TileHeight, float64(layer.Opacity))
+
+		p := getItemProps(getTileType(tile), getTileProps(tile), nil, objectTypes)
+		for _, c := range tileColliders {
+			cells[c.prop][i] = p.GetBool(c.prop, false)
+		}
+	}
+
+	if layer.Visible {
+		z := getItemProps("", nil, layer.Properties, nil).GetInt("z", com.ZTerrain)
+		l := com.NewSprite(sha.IDTileLayer, img, 0, 0, z, com.Vector{})
+		w.addDrawable(&l, w.loadLayer)
+	}
+
+	// colliders are not drawn, the layer image shows them
+	for _, c := range tileColliders {
+		for _, r := range mergeCells(cells[c.prop], m.Width, m.Height) {
+			p := getItemProps(c.typ, nil, nil, objectTypes)
+			p.Set("draw", "bool", "false")
+			a := com.ItemArgs{
+				Name: layer.Name,
+				X:    layer.OffsetX + r.Min.X*m.TileWidth, Y: layer.OffsetY + r.Min.Y*m.TileHeight,
+				W: r.Dx() * m.TileWidth, H: r.Dy() * m.TileHeight,
+				Props: p,
+			}
+			if err := w.addLevelItem(a, c.typ, mapPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// draws a tile in the layer image, tiles bigger than the map grid are aligned at the bottom left (like Tiled)
+func drawTile(dst, tileImg *ebiten.Image, tile *tiled.LayerTile, x, y, tileH int, alpha float64) {
+	w, h := tileImg.Size()
+	op := &ebiten.DrawImageOptions{}
+	// flip around the center of the tile
+	op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+	if tile.DiagonalFlip {
+		op.GeoM.Rotate(math.Pi / 2)
+		op.GeoM.Scale(-1, 1)
+	}
+	if tile.HorizontalFlip {
+		op.GeoM.Scale(-1, 1)
+	}
+	if tile.VerticalFlip {
+		op.GeoM.Scale(1, -1)
+	}
+	op.GeoM.Translate(float64(w)/2, float64(h)/2)
+	if offset := tile.Tileset.TileOffset; offset != nil {
+		x, y = x+offset.X, y+offset.Y
+	}
+	op.GeoM.Translate(float64(x), float64(y+tileH-h))
+	op.ColorM.Scale(1, 1, 1, alpha)
+	dst.DrawImage(tileImg, op)
+}
+
+// returns the image of a tile, from an image collection tileset or a part of a tilesheet
+func getTileImage(tile *tiled.LayerTile, images tileImages) (*ebiten.Image, error) {
+	ts := tile.Tileset
+	if t := getTilesetTile(tile); t != nil && t.Image != nil {
+		return loadTileImage(ts.GetFileFullPath(t.Image.Source), images)
+	}
+	sheet, err := loadTileImage(ts.GetFileFullPath(ts.Image.Source), images)
+	if err != nil {
+		return nil, err
+	}
+	return sheet.SubImage(ts.GetTileRect(tile.ID)).(*ebiten.Image), nil
+}
+
+func loadTileImage(path string, images tileImages) (*ebiten.Image, error) {
+	if img, ok := images[path]; ok {
+		return img, nil
+	}
+	img, _, err := ebitenutil.NewImageFromFile(path, ebiten.FilterDefault)
+	if err != nil {
+		return nil, err
+	}
+	images[path] = img
+	return img, nil
+}
+
+// mergeCells merges the set cells of a grid to rectangles (in cells),
+// first as wide as possible, then as high as possible with the same width
+func mergeCells(cells []bool, w, h int) []image.Rectangle {
+	var rects []image.Rectangle
+	used := make([]bool, len(cells))
+	free := func(x, y int) bool {
+		return cells[x+y*w] && !used[x+y*w]
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !free(x, y) {
+				continue
+			}
+			rw := 1
+			for x+rw < w && free(x+rw, y) {
+				rw++
+			}
+			rh := 1
+			for y+rh < h {
+				row := true
+				for i := x; i < x+rw; i++ {
+					if !free(i, y+rh) {
+						row = false
+						break
+					}
+				}
+				if !row {
+					break
+				}
+				rh++
+			}
+			for j := y; j < y+rh; j++ {
+				for i := x; i < x+rw; i++ {
+					used[i+j*w] = true
+				}
+			}
+			rects = append(rects, image.Rect(x, y, x+rw, y+rh))
+		}
+	}
+	return rects
+}
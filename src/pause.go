@@ -0,0 +1,111 @@
+package src
+
+import (
+	gui "moonlander/src/gui"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// Settings are the options of the settings menu
+type Settings struct {
+	Ghost bool
+}
+
+var (
+	settings = Settings{Ghost: true}
+)
+
+// pause menu actions
+const (
+	actionResume     = "resume"
+	actionRestart    = "restart"
+	actionSettings   = "settings"
+	actionMenu       = "menu"
+	actionGhost      = "ghost"
+	actionFullscreen = "fullscreen"
+	actionBack       = "back"
+)
+
+// PauseScene is an overlay over a level, the level is not updated while it is on top,
+// so its simulation and lap timers are frozen
+type PauseScene struct {
+	world *World
+	menu  *gui.Menu
+}
+
+// NewPauseScene constructor
+func NewPauseScene(w *World) *PauseScene {
+	return &PauseScene{world: w}
+}
+
+// Enter implements Scene
+func (s *PauseScene) Enter(g *Game) {
+	s.menu = pauseMenu()
+}
+
+// Exit implements Scene
+func (s *PauseScene) Exit(g *Game) {
+}
+
+// Update implements Scene
+func (s *PauseScene) Update(g *Game, screen *ebiten.Image) error {
+	// wait for the focus to come back
+	if !ebiten.IsFocused() {
+		return nil
+	}
+	switch s.menu.Update(screen) {
+	case actionResume:
+		g.Pop(nil)
+	case actionRestart:
+		startLevel(g, s.world.Info.ID)
+	case actionSettings:
+		s.menu = settingsMenu()
+	case actionMenu:
+		g.Switch(&TitleScene{}, fade())
+	case actionGhost:
+		settings.Ghost = !settings.Ghost
+		s.world.ShowGhost(settings.Ghost)
+		s.menu = settingsMenu()
+	case actionFullscreen:
+		ebiten.SetFullscreen(!ebiten.IsFullscreen())
+		s.menu = settingsMenu()
+	case actionBack:
+		s.menu = pauseMenu()
+	default:
+		// escape resumes, only when no menu action changed the scenes already
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.Pop(nil)
+		}
+	}
+	return nil
+}
+
+// Draw implements Scene
+func (s *PauseScene) Draw(screen *ebiten.Image) {
+	s.menu.Draw(screen)
+}
+
+func pauseMenu() *gui.Menu {
+	return gui.NewMenu("PAUSED", []gui.MenuItem{
+		{Name: actionResume, Text: "Resume"},
+		{Name: actionRestart, Text: "Restart"},
+		{Name: actionSettings, Text: "Settings"},
+		{Name: actionMenu, Text: "Quit to Menu"},
+	})
+}
+
+func settingsMenu() *gui.Menu {
+	return gui.NewMenu("SETTINGS", []gui.MenuItem{
+		{Name: actionGhost, Text: "Ghost: " + onOff(settings.Ghost)},
+		{Name: actionFullscreen, Text: "Fullscreen: " + onOff(ebiten.IsFullscreen())},
+		{Name: actionBack, Text: "Back"},
+	})
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
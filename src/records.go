@@ -0,0 +1,222 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	sha "moonlander/src/shared"
+)
+
+// number of lap and total times kept per level
+const maxRecords = 10
+
+// Record is a time set in a level, the replay is the file of the run it was set in
+type Record struct {
+	Name   string    `json:"name"`
+	Ticks  int       `json:"ticks"`
+	Date   time.Time `json:"date"`
+	Replay string    `json:"replay,omitempty"`
+	// split times of a lap record
+	Splits []int `json:"splits,omitempty"`
+}
+
+// LevelRecords are the best lap and total times of a level, fastest first
+type LevelRecords struct {
+	Laps   []Record `json:"laps"`
+	Totals []Record `json:"totals"`
+}
+
+// Records is the local records store, a JSON file in the user config dir
+type Records struct {
+	Levels map[string]*LevelRecords `json:"levels"`
+	path   string
+}
+
+var (
+	records *Records
+)
+
+// recordsPath is the file of the records store, empty when there is no user config dir
+func recordsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		fmt.Printf("warning: records are not kept: %v\n", err)
+		return ""
+	}
+	return filepath.Join(dir, "moonlander", "records.json")
+}
+
+// LoadRecords reads the records store, a missing file gives empty records.
+// A corrupt file is moved aside (.bad), so it is not overwritten, and the records start empty
+func LoadRecords(path string) *Records {
+	r := &Records{Levels: make(map[string]*LevelRecords), path: path}
+	if path == "" {
+		return r
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r
+	}
+	if err != nil {
+		fmt.Printf("warning: records not loaded: %v\n", err)
+		return r
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		fmt.Printf("warning: %v: corrupt records moved to %v.bad: %v\n", path, path, err)
+		os.Rename(path, path+".bad")
+		r.Levels = make(map[string]*LevelRecords)
+	}
+	// a store without levels (like {}) is empty, a level without records (null) is left out
+	if r.Levels == nil {
+		r.Levels = make(map[string]*LevelRecords)
+	}
+	for id, l := range r.Levels {
+		if l == nil {
+			delete(r.Levels, id)
+		}
+	}
+	return r
+}
+
+// Save writes the records store, through a temporary file so a failed write keeps the old records
+func (r *Records) Save() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// Get returns the records of a level
+func (r *Records) Get(level string) LevelRecords {
+	if l, ok := r.Levels[level]; ok {
+		return *l
+	}
+	return LevelRecords{}
+}
+
+// Qualifies checks if a race with these lap times would set a lap or total record
+func (r *Records) Qualifies(level string, lapTicks []int) bool {
+	l := r.Get(level)
+	for _, t := range lapTicks {
+		if qualifies(l.Laps, t) {
+			return true
+		}
+	}
+	return qualifies(l.Totals, totalTicks(lapTicks))
+}
+
+// Add adds the lap times (with their split times) and the total time of a race, and returns the places (1 is best)
+// of the best new lap record and of the total, 0 when it is not a record
+func (r *Records) Add(level string, lapTicks []int, lapSplits [][]int, rec Record) (lapPlace, totalPlace int) {
+	l, ok := r.Levels[level]
+	if !ok {
+		l = &LevelRecords{}
+		r.Levels[level] = l
+	}
+	for i, t := range lapTicks {
+		lap := rec
+		lap.Ticks = t
+		if i < len(lapSplits) {
+			lap.Splits = lapSplits[i]
+		}
+		var p int
+		if l.Laps, p = insertRecord(l.Laps, lap); p > 0 && (lapPlace == 0 || p < lapPlace) {
+			lapPlace = p
+		}
+	}
+	total := rec
+	total.Ticks = totalTicks(lapTicks)
+	l.Totals, totalPlace = insertRecord(l.Totals, total)
+	return lapPlace, totalPlace
+}
+
+// BestSplits returns the ticks and the split times of the lap record of a level
+func (r *Records) BestSplits(level string) (int, []int) {
+	l := r.Get(level)
+	if len(l.Laps) == 0 {
+		return 0, nil
+	}
+	return l.Laps[0].Ticks, l.Laps[0].Splits
+}
+
+// Summaries returns the best lap and total of every level with records, as text
+func (r *Records) Summaries() map[string]string {
+	s := make(map[string]string)
+	for id, l := range r.Levels {
+		if len(l.Laps) > 0 && len(l.Totals) > 0 {
+			s[id] = fmt.Sprintf("lap   %v\ntotal %v", sha.FormatTicks(l.Laps[0].Ticks), sha.FormatTicks(l.Totals[0].Ticks))
+		}
+	}
+	return s
+}
+
+// Leaderboard returns the records of a level as text, the lap times above the total times
+func (r *Records) Leaderboard(level string) string {
+	l := r.Get(level)
+	return "BEST LAPS\n" + recordsText(l.Laps) + "\nBEST TOTALS\n" + recordsText(l.Totals)
+}
+
+func recordsText(list []Record) string {
+	var str string
+	for i, rec := range list {
+		str += fmt.Sprintf("%2d. %v  %v  %v\n", i+1, sha.FormatTicks(rec.Ticks), rec.Name, rec.Date.Format("02-01-2006"))
+	}
+	if str == "" {
+		str = "-\n"
+	}
+	return str
+}
+
+// insertRecord inserts a record in a list of the fastest times, and returns the place it got (0 when it didn't make it).
+// An equal time doesn't beat an older record
+func insertRecord(list []Record, rec Record) ([]Record, int) {
+	i := sort.Search(len(list), func(i int) bool { return list[i].Ticks > rec.Ticks })
+	if i >= maxRecords {
+		return list, 0
+	}
+	list = append(list, Record{})
+	copy(list[i+1:], list[i:])
+	list[i] = rec
+	if len(list) > maxRecords {
+		list = list[:maxRecords]
+	}
+	return list, i + 1
+}
+
+func qualifies(list []Record, ticks int) bool {
+	return len(list) < maxRecords || ticks < list[len(list)-1].Ticks
+}
+
+func totalTicks(lapTicks []int) int {
+	var t int
+	for _, l := range lapTicks {
+		t += l
+	}
+	return t
+}
+
+// playerName is the name records are set with, the name of the user
+func playerName() string {
+	for _, env := range []string{"USER", "USERNAME"} {
+		if n := os.Getenv(env); n != "" {
+			return n
+		}
+	}
+	return "player"
+}
@@ -0,0 +1,146 @@
+package src
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "records")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		data    *string // nil is no file
+		levels  int
+		corrupt bool
+	}{
+		{"missing", nil, 0, false},
+		{"empty", strp(""), 0, true},
+		{"no levels", strp(`{}`), 0, false},
+		{"null levels", strp(`{"levels":null}`), 0, false},
+		{"invalid", strp(`{"levels":`), 0, true},
+		{"null level", strp(`{"levels":{"level01":null,"level02":{"laps":[{"ticks":60}],"totals":[{"ticks":180}]}}}`), 1, false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name+".json")
+		if tt.data != nil {
+			if err := ioutil.WriteFile(path, []byte(*tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		r := LoadRecords(path)
+		if r.Levels == nil || len(r.Levels) != tt.levels {
+			t.Errorf("%s: %d levels, want %d", tt.name, len(r.Levels), tt.levels)
+		}
+		for id, l := range r.Levels {
+			if l == nil {
+				t.Errorf("%s: level %v has nil records", tt.name, id)
+			}
+		}
+		// nothing may crash on what was loaded
+		r.Summaries()
+		r.Get("level01")
+		r.Leaderboard("level01")
+		if _, err := os.Stat(path + ".bad"); (err == nil) != tt.corrupt {
+			t.Errorf("%s: moved aside %v, want %v", tt.name, err == nil, tt.corrupt)
+		}
+	}
+}
+
+func strp(s string) *string {
+	return &s
+}
+
+func ticksOf(list []Record) []int {
+	var t []int
+	for _, r := range list {
+		t = append(t, r.Ticks)
+	}
+	return t
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestInsertRecord(t *testing.T) {
+	var list []Record
+	for _, tt := range []struct {
+		ticks, place int
+		want         []int
+	}{
+		{50, 1, []int{50}},
+		{30, 1, []int{30, 50}},
+		{40, 2, []int{30, 40, 50}},
+		// an equal time doesn't beat an older record
+		{40, 3, []int{30, 40, 40, 50}},
+		{60, 5, []int{30, 40, 40, 50, 60}},
+	} {
+		var place int
+		list, place = insertRecord(list, Record{Ticks: tt.ticks, Name: "new"})
+		if place != tt.place || !equalInts(ticksOf(list), tt.want) {
+			t.Errorf("insert %d: place %d, list %v, want %d, %v", tt.ticks, place, ticksOf(list), tt.place, tt.want)
+		}
+	}
+	if list[1].Name != "new" || list[2].Name != "new" {
+		t.Errorf("names changed")
+	}
+
+	// a full list drops the slowest, and a time slower than all doesn't make it
+	for len(list) < maxRecords {
+		list, _ = insertRecord(list, Record{Ticks: 100})
+	}
+	if _, place := insertRecord(list, Record{Ticks: 100}); place != 0 {
+		t.Errorf("equal to the slowest of a full list got place %d", place)
+	}
+	list, place := insertRecord(list, Record{Ticks: 10})
+	if place != 1 || len(list) != maxRecords || list[len(list)-1].Ticks != 100 {
+		t.Errorf("insert in full list: place %d, list %v", place, ticksOf(list))
+	}
+}
+
+func TestAddQualifies(t *testing.T) {
+	r := &Records{Levels: make(map[string]*LevelRecords)}
+	if !r.Qualifies("level01", []int{100, 100}) {
+		t.Error("first race doesn't qualify")
+	}
+	lap, total := r.Add("level01", []int{120, 90, 100}, nil, Record{Name: "a"})
+	if lap != 1 || total != 1 {
+		t.Errorf("first race places %d %d, want 1 1", lap, total)
+	}
+	if got := ticksOf(r.Get("level01").Laps); !equalInts(got, []int{90, 100, 120}) {
+		t.Errorf("laps %v", got)
+	}
+
+	// fill the lists with faster races
+	for i := 0; i < maxRecords; i++ {
+		r.Add("level01", []int{50, 50, 50}, nil, Record{Name: "b"})
+	}
+	if r.Qualifies("level01", []int{60, 60, 60}) {
+		t.Error("slower race qualifies for full lists")
+	}
+	if !r.Qualifies("level01", []int{60, 49, 60}) {
+		t.Error("faster lap doesn't qualify")
+	}
+	lap, total = r.Add("level01", []int{60, 49, 60}, nil, Record{Name: "c"})
+	if lap != 1 || total != 0 {
+		t.Errorf("faster lap places %d %d, want 1 0", lap, total)
+	}
+	if l := r.Get("level01"); len(l.Laps) != maxRecords || len(l.Totals) != maxRecords {
+		t.Errorf("%d laps and %d totals kept, want %d", len(l.Laps), len(l.Totals), maxRecords)
+	}
+}
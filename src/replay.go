@@ -0,0 +1,191 @@
+package src
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	com "moonlander/src/components"
+	sha "moonlander/src/shared"
+)
+
+// directory the replays are saved in
+const replayDir = "replays"
+
+// replay file header, followed by the version of the format
+const (
+	replayMagic   = "MLRP"
+	replayVersion = 1
+)
+
+// limits of a replay file, so a corrupt file can't make us allocate a lot of memory:
+// the level id is a file name, and a run of more than a day is not a run
+const (
+	maxReplayLevel = 255
+	maxReplayTicks = 24 * 60 * 60 * sha.TPS
+)
+
+// Replay is a recorded run, the level, the seed of its world and the packed controls of every tick.
+// The simulation is deterministic, so playing the controls in the same world gives the same run
+type Replay struct {
+	Level string
+	Seed  int64
+	Ticks []byte
+}
+
+// Write writes the replay in the compact file format: the header, the level id, the seed,
+// and the controls run length encoded (ticks in a row with the same controls)
+func (r *Replay) Write(wr io.Writer) error {
+	bw := bufio.NewWriter(wr)
+	buf := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) {
+		bw.Write(buf[:binary.PutUvarint(buf, v)])
+	}
+	bw.WriteString(replayMagic)
+	bw.WriteByte(replayVersion)
+	putUvarint(uint64(len(r.Level)))
+	bw.WriteString(r.Level)
+	bw.Write(buf[:binary.PutVarint(buf, r.Seed)])
+	putUvarint(uint64(len(r.Ticks)))
+	for i := 0; i < len(r.Ticks); {
+		n := 1
+		for i+n < len(r.Ticks) && r.Ticks[i+n] == r.Ticks[i] {
+			n++
+		}
+		putUvarint(uint64(n))
+		bw.WriteByte(r.Ticks[i])
+		i += n
+	}
+	return bw.Flush()
+}
+
+// ReadReplay reads a replay written by Write
+func ReadReplay(rd io.Reader) (*Replay, error) {
+	br := bufio.NewReader(rd)
+	head := make([]byte, len(replayMagic)+1)
+	if _, err := io.ReadFull(br, head); err != nil {
+		return nil, fmt.Errorf("replay header: %v", err)
+	}
+	if string(head[:len(replayMagic)]) != replayMagic {
+		return nil, fmt.Errorf("not a replay file")
+	}
+	if head[len(replayMagic)] != replayVersion {
+		return nil, fmt.Errorf("unsupported replay version %v", head[len(replayMagic)])
+	}
+
+	r := &Replay{}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("replay level: %v", err)
+	}
+	if n > maxReplayLevel {
+		return nil, fmt.Errorf("replay level: id of %v bytes is too long", n)
+	}
+	level := make([]byte, n)
+	if _, err := io.ReadFull(br, level); err != nil {
+		return nil, fmt.Errorf("replay level: %v", err)
+	}
+	r.Level = string(level)
+	if r.Seed, err = binary.ReadVarint(br); err != nil {
+		return nil, fmt.Errorf("replay seed: %v", err)
+	}
+	total, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("replay ticks: %v", err)
+	}
+	if total > maxReplayTicks {
+		return nil, fmt.Errorf("replay ticks: %v ticks is too long", total)
+	}
+	for uint64(len(r.Ticks)) < total {
+		run, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("replay ticks: %v", err)
+		}
+		bits, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("replay ticks: %v", err)
+		}
+		if run == 0 || uint64(len(r.Ticks))+run > total {
+			return nil, fmt.Errorf("replay ticks: bad run of %v ticks", run)
+		}
+		for i := uint64(0); i < run; i++ {
+			r.Ticks = append(r.Ticks, bits)
+		}
+	}
+	return r, nil
+}
+
+// Save writes the replay to a file
+func (r *Replay) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadReplay reads a replay from a file
+func LoadReplay(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := ReadReplay(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return r, nil
+}
+
+// recordInput passes the controls of an input through, and records them in a replay
+type recordInput struct {
+	in     com.Input
+	replay *Replay
+}
+
+// Read implements com.Input
+func (i *recordInput) Read() com.Controls {
+	c := i.in.Read()
+	i.replay.Ticks = append(i.replay.Ticks, c.Bits())
+	return c
+}
+
+// playbackInput plays the controls of a replay, after the last tick nothing is pressed
+type playbackInput struct {
+	replay *Replay
+	tick   int
+}
+
+// Read implements com.Input
+func (i *playbackInput) Read() com.Controls {
+	if i.tick >= len(i.replay.Ticks) {
+		return com.Controls{}
+	}
+	c := com.ControlsFromBits(i.replay.Ticks[i.tick])
+	i.tick++
+	return c
+}
+
+// PlayReplay loads the level of a replay in a new World, the player is controlled by the replay
+func PlayReplay(r *Replay) (*World, error) {
+	w, err := LoadLevel(r.Level, r.Seed)
+	if err != nil {
+		return nil, err
+	}
+	w.replay = nil
+	if w.player != nil {
+		w.player.SetInput(&playbackInput{replay: r})
+	}
+	return w, nil
+}
@@ -0,0 +1,73 @@
+package src
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func testReplay() *Replay {
+	r := &Replay{Level: "level02", Seed: -1234567890123}
+	// long runs, single ticks and all bits
+	for i := 0; i < 1000; i++ {
+		r.Ticks = append(r.Ticks, 1)
+	}
+	for i := 0; i < 128; i++ {
+		r.Ticks = append(r.Ticks, byte(i))
+	}
+	for i := 0; i < 5000; i++ {
+		r.Ticks = append(r.Ticks, 0)
+	}
+	return r
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	r := testReplay()
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// runs are stored once
+	if buf.Len() > 400 {
+		t.Errorf("replay of %d ticks takes %d bytes", len(r.Ticks), buf.Len())
+	}
+	got, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Level != r.Level || got.Seed != r.Seed || !bytes.Equal(got.Ticks, r.Ticks) {
+		t.Errorf("read %v %v and %d ticks, want %v %v and %d ticks", got.Level, got.Seed, len(got.Ticks), r.Level, r.Seed, len(r.Ticks))
+	}
+}
+
+func TestReplayTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReplay().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	for n := 0; n < len(data); n++ {
+		if _, err := ReadReplay(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("replay cut at %d of %d bytes read without error", n, len(data))
+		}
+	}
+}
+
+func TestReplayTooLong(t *testing.T) {
+	huge := make([]byte, binary.MaxVarintLen64)
+	huge = huge[:binary.PutUvarint(huge, 1<<60)]
+	head := append([]byte(replayMagic), replayVersion)
+
+	// a level id length which doesn't fit in memory
+	level := append(append([]byte{}, head...), huge...)
+	if _, err := ReadReplay(bytes.NewReader(level)); err == nil {
+		t.Error("replay with a huge level id read without error")
+	}
+
+	// a tick count which doesn't fit in memory
+	ticks := append(append([]byte{}, head...), 1, 'x', 0)
+	ticks = append(ticks, huge...)
+	if _, err := ReadReplay(bytes.NewReader(ticks)); err == nil {
+		t.Error("replay with a huge tick count read without error")
+	}
+}
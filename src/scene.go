@@ -0,0 +1,112 @@
+package src
+
+import (
+	"image/color"
+
+	sha "moonlander/src/shared"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// Scene is a screen of the game, like the title screen, a level or a dialog.
+// Scenes are on a stack, only the top scene is updated, all scenes are drawn (bottom first),
+// so a dialog can overlay a level which stays as it is
+type Scene interface {
+	// Enter is called when the scene gets on the stack
+	Enter(g *Game)
+	// Exit is called when the scene leaves the stack
+	Exit(g *Game)
+	Update(g *Game, screen *ebiten.Image) error
+	Draw(screen *ebiten.Image)
+}
+
+// Transition is drawn over the scenes while they change, nil is an instant change
+type Transition interface {
+	// Update proceeds the transition one tick, change is true on the tick the scenes change
+	// (when the screen is covered), done when the transition is over
+	Update() (change, done bool)
+	Draw(screen *ebiten.Image)
+}
+
+// Switch replaces all scenes on the stack by a scene
+func (g *Game) Switch(s Scene, t Transition) {
+	g.changeScenes(func() {
+		for len(g.scenes) > 0 {
+			g.popScene()
+		}
+		g.pushScene(s)
+	}, t)
+}
+
+// Push puts a scene on top of the stack
+func (g *Game) Push(s Scene, t Transition) {
+	g.changeScenes(func() {
+		g.pushScene(s)
+	}, t)
+}
+
+// Pop removes the top scene, the scene below it continues
+func (g *Game) Pop(t Transition) {
+	g.changeScenes(func() {
+		g.popScene()
+	}, t)
+}
+
+// changeScenes applies a change of scenes now, or halfway a transition
+func (g *Game) changeScenes(change func(), t Transition) {
+	if t == nil {
+		change()
+		return
+	}
+	g.transition, g.change = t, change
+}
+
+func (g *Game) pushScene(s Scene) {
+	g.scenes = append(g.scenes, s)
+	s.Enter(g)
+}
+
+func (g *Game) popScene() {
+	if len(g.scenes) == 0 {
+		return
+	}
+	s := g.scenes[len(g.scenes)-1]
+	g.scenes = g.scenes[:len(g.scenes)-1]
+	s.Exit(g)
+}
+
+// Fade is a Transition which fades the screen to a color and back
+type Fade struct {
+	ticks, tick int
+	img         *ebiten.Image
+}
+
+// NewFade constructor, the fade out and in together take ticks
+func NewFade(ticks int, c color.RGBA) *Fade {
+	img, _ := ebiten.NewImage(sha.ScreenWidth, sha.ScreenHeight, ebiten.FilterDefault)
+	img.Fill(c)
+	return &Fade{ticks: maxInt(ticks, 2), img: img}
+}
+
+// Update implements Transition, the scenes change when the screen is fully covered
+func (o *Fade) Update() (bool, bool) {
+	o.tick++
+	return o.tick == o.ticks/2, o.tick >= o.ticks
+}
+
+// Draw implements Transition
+func (o *Fade) Draw(screen *ebiten.Image) {
+	half := o.ticks / 2
+	alpha := float64(o.tick) / float64(half)
+	if o.tick > half {
+		alpha = float64(o.ticks-o.tick) / float64(o.ticks-half)
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(1, 1, 1, alpha)
+	screen.DrawImage(o.img, op)
+}
+
+// the default transition between screens
+func fade() Transition {
+	return NewFade(sha.TPS/2, sha.Black)
+}
@@ -0,0 +1,179 @@
+package src
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	gui "moonlander/src/gui"
+	sha "moonlander/src/shared"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// ticks the race goes on after the finish, before the result is shown
+const resultDelay = 2 * sha.TPS
+
+// TitleScene shows the level catalogue, with an optional message (e.g. an error)
+type TitleScene struct {
+	message string
+}
+
+// Enter implements Scene
+func (s *TitleScene) Enter(g *Game) {
+	initTitle()
+	if s.message != "" {
+		gui.SetTitleMessage(s.message)
+	}
+}
+
+// Exit implements Scene
+func (s *TitleScene) Exit(g *Game) {
+	gui.ClearTitle()
+}
+
+// Update implements Scene, a clicked level is loaded
+func (s *TitleScene) Update(g *Game, screen *ebiten.Image) error {
+	if id := gui.UpdateTitle(screen); id != "" {
+		startLevel(g, id)
+	}
+	return nil
+}
+
+// Draw implements Scene
+func (s *TitleScene) Draw(screen *ebiten.Image) {
+	gui.DrawTitle(screen)
+}
+
+// LevelScene plays a World
+type LevelScene struct {
+	world  *World
+	result gui.Result
+}
+
+// NewLevelScene constructor
+func NewLevelScene(w *World) *LevelScene {
+	return &LevelScene{world: w}
+}
+
+// Enter implements Scene
+func (s *LevelScene) Enter(g *Game) {
+}
+
+// Exit implements Scene, the run is kept as the last replay of its level
+func (s *LevelScene) Exit(g *Game) {
+	if r := s.world.Replay(); r != nil {
+		if err := r.Save(filepath.Join(replayDir, r.Level+".last.mlr")); err != nil {
+			fmt.Printf("warning: replay not saved: %v\n", err)
+		}
+	}
+}
+
+// Update implements Scene
+func (s *LevelScene) Update(g *Game, screen *ebiten.Image) error {
+	if err := s.world.Update(screen); err != nil {
+		return err
+	}
+	if s.world.JustFinished() {
+		lapPlace, totalPlace := submitRecords(s.world)
+		s.result = raceResult(s.world, lapPlace, totalPlace)
+	}
+	// show the result a moment after the finish
+	if s.world.Finished() && s.world.TicksSinceFinish() == resultDelay {
+		g.Switch(&ResultScene{result: s.result, level: s.world.Info.ID}, fade())
+	}
+
+	// test game-over screen
+	if ebiten.IsKeyPressed(ebiten.KeyBackslash) {
+		g.Switch(&GameOverScene{}, fade())
+	}
+	// pause on escape, or when the window loses focus
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || !ebiten.IsFocused() {
+		g.Push(NewPauseScene(s.world), nil)
+	}
+	return nil
+}
+
+// Draw implements Scene
+func (s *LevelScene) Draw(screen *ebiten.Image) {
+	s.world.Draw(screen)
+}
+
+// GameOverScene is shown when the game is over
+type GameOverScene struct{}
+
+// Enter implements Scene
+func (s *GameOverScene) Enter(g *Game) {
+	gui.InitGameOver()
+}
+
+// Exit implements Scene
+func (s *GameOverScene) Exit(g *Game) {
+	gui.ClearGameOver()
+}
+
+// Update implements Scene
+func (s *GameOverScene) Update(g *Game, screen *ebiten.Image) error {
+	if gui.UpdateGameOver(screen) != "" || ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		g.Switch(&TitleScene{}, fade())
+	}
+	return nil
+}
+
+// Draw implements Scene
+func (s *GameOverScene) Draw(screen *ebiten.Image) {
+	gui.DrawGameOver(screen)
+}
+
+// ResultScene shows the result of a race in a level
+type ResultScene struct {
+	result gui.Result
+	level  string
+}
+
+// Enter implements Scene
+func (s *ResultScene) Enter(g *Game) {
+	gui.InitResult(s.result)
+}
+
+// Exit implements Scene
+func (s *ResultScene) Exit(g *Game) {
+	gui.ClearResult()
+}
+
+// Update implements Scene
+func (s *ResultScene) Update(g *Game, screen *ebiten.Image) error {
+	switch gui.UpdateResult(screen) {
+	case gui.ActionRetry:
+		startLevel(g, s.level)
+	case gui.ActionNext:
+		if next, ok := nextLevel(s.level); ok {
+			startLevel(g, next.ID)
+		}
+	case gui.ActionMenu:
+		g.Switch(&TitleScene{}, fade())
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		g.Switch(&TitleScene{}, fade())
+	}
+	return nil
+}
+
+// Draw implements Scene
+func (s *ResultScene) Draw(screen *ebiten.Image) {
+	gui.DrawResult(screen)
+}
+
+// startLevel loads a level and switches to it, when it fails to load the title screen tells what went wrong
+func startLevel(g *Game, id string) {
+	world, err := LoadLevel(id, time.Now().UnixNano())
+	if err != nil {
+		fmt.Println("level failed to load:", err)
+		g.Switch(&TitleScene{message: "level failed to load\n" + err.Error()}, nil)
+		return
+	}
+	world.SetBestSplits(records.BestSplits(id))
+	world.ShowGhost(settings.Ghost)
+	g.Switch(NewLevelScene(world), fade())
+}
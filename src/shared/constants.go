@@ -13,6 +13,7 @@ var (
 	Cyan   = color.RGBA{0, 255, 255, 255}
 	Purple = color.RGBA{255, 0, 255, 255}
 	White  = color.RGBA{255, 255, 255, 255}
+	Black  = color.RGBA{0, 0, 0, 255}
 
 	Red50    = color.RGBA{255, 0, 0, 128}
 	Green50  = color.RGBA{0, 255, 0, 128}
@@ -30,16 +31,34 @@ var (
 	Purple25 = color.RGBA{255, 0, 255, 64}
 	White25  = color.RGBA{255, 255, 255, 64}
 
+	// translate color names (used in Tiled properties) to colors
+	ColorNames = map[string]color.RGBA{
+		"red":    Red,
+		"green":  Green,
+		"blue":   Blue,
+		"yellow": Yellow,
+		"cyan":   Cyan,
+		"teal":   Cyan,
+		"purple": Purple,
+		"white":  White,
+		"black":  Black,
+	}
+
 	// translate ids to name string
 	Name = map[int]string{
-		0: "unknown",
-		1: "player",
-		2: "background",
-		3: "square",
-		4: "wall",
-		5: "tester",
-		6: "finish",
-		7: "checkpoint",
+		0:  "unknown",
+		1:  "player",
+		2:  "background",
+		3:  "square",
+		4:  "wall",
+		5:  "tester",
+		6:  "finish",
+		7:  "checkpoint",
+		8:  "spawner",
+		9:  "tilelayer",
+		10: "hazard",
+		11: "terrain",
+		12: "ghost",
 	}
 )
 
@@ -55,4 +74,9 @@ const (
 	IDTester     = 5
 	IDFinish     = 6
 	IDCheckpoint = 7
+	IDSpawner    = 8
+	IDTileLayer  = 9
+	IDHazard     = 10
+	IDTerrain    = 11
+	IDGhost      = 12
 )
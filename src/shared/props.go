@@ -0,0 +1,136 @@
+package shared
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prop is a single Tiled property, the value is kept as string and converted on request
+type Prop struct {
+	Type  string
+	Value string
+}
+
+// Props are the resolved properties of a level item,
+// object type defaults merged with tileset tile and per object values
+type Props map[string]Prop
+
+// Set adds or overrides a property, an empty type keeps the type of the overridden property
+func (p Props) Set(name, typ, value string) {
+	if old, ok := p[name]; ok && typ == "" {
+		typ = old.Type
+	}
+	p[name] = Prop{Type: typ, Value: value}
+}
+
+// Merge adds or overrides all properties of other
+func (p Props) Merge(other Props) {
+	for name, prop := range other {
+		p.Set(name, prop.Type, prop.Value)
+	}
+}
+
+// Has returns true when the property is set
+func (p Props) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// GetString returns the property value, or def when not set
+func (p Props) GetString(name, def string) string {
+	if prop, ok := p[name]; ok {
+		return prop.Value
+	}
+	return def
+}
+
+// GetBool returns the property as bool ("true" or "1"), or def when not set or invalid
+func (p Props) GetBool(name string, def bool) bool {
+	if prop, ok := p[name]; ok {
+		if v, err := strconv.ParseBool(prop.Value); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// GetInt returns the property as int, or def when not set or invalid
+func (p Props) GetInt(name string, def int) int {
+	if prop, ok := p[name]; ok {
+		if v, err := strconv.Atoi(prop.Value); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// GetFloat returns the property as float64, or def when not set or invalid
+func (p Props) GetFloat(name string, def float64) float64 {
+	if prop, ok := p[name]; ok {
+		if v, err := strconv.ParseFloat(prop.Value, 64); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// GetColor returns the property as color, or def when not set or invalid
+// Tiled stores colors as #AARRGGBB, color names (see ColorNames) are also accepted
+func (p Props) GetColor(name string, def color.RGBA) color.RGBA {
+	if prop, ok := p[name]; ok {
+		if v, err := parseColor(prop.Value); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// Check validates the values of all typed properties,
+// returns the name of the first (by name) invalid property and the parse error
+func (p Props) Check() (string, error) {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := p[name]
+		var err error
+		switch prop.Type {
+		case "bool":
+			_, err = strconv.ParseBool(prop.Value)
+		case "int":
+			_, err = strconv.Atoi(prop.Value)
+		case "float":
+			_, err = strconv.ParseFloat(prop.Value, 64)
+		case "color":
+			_, err = parseColor(prop.Value)
+		}
+		if err != nil {
+			return name, err
+		}
+	}
+	return "", nil
+}
+
+// parseColor parses #AARRGGBB, #RRGGBB or a color name
+func parseColor(s string) (color.RGBA, error) {
+	if c, ok := ColorNames[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 6 {
+		hex = "ff" + hex
+	}
+	if len(hex) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), uint8(v >> 24)}, nil
+}
@@ -1,10 +1,8 @@
 package shared
 
-import "time"
-
-// common used variables in components, values often set per level
-var (
-	LP LevelProperties
+import (
+	"fmt"
+	"time"
 )
 
 // LevelProperties are used to store info / progress off the level
@@ -19,6 +17,72 @@ type LevelProperties struct {
 	Lives        int
 	CurrentLap   int
 	MaxLaps      int
-	LapTimes     []time.Duration
-	LapStartTime time.Time
+	Clock        Clock
+	LapTicks     []int
+	LapStartTick int
+	LapStarted   bool
+	// split times (ticks since the lap start) per checkpoint, -1 when not passed, of this lap and of the done laps,
+	// and of the best lap to compare with
+	Splits     []int
+	LapSplits  [][]int
+	BestSplits []int
+}
+
+// TPS is the number of simulation ticks per second
+const TPS = 60
+
+// DT is the length of a tick in seconds, physics values (velocity, thrust, gravity) are per second
+// and scaled by DT every tick
+const DT = 1.0 / TPS
+
+// Debug enables the debug tools, like steering the tester object from the keyboard.
+// Debug input isn't recorded, so replays of a debug run may not play back the same
+var Debug bool
+
+// Clock is the simulation clock of a level, it counts fixed ticks of 1/TPS second
+// so results don't depend on the frame rate or the wall clock
+type Clock struct {
+	Tick int
+}
+
+// Step advances the clock one tick
+func (c *Clock) Step() {
+	c.Tick++
+}
+
+// TicksToDuration converts a number of ticks to a duration
+func TicksToDuration(ticks int) time.Duration {
+	return time.Duration(ticks) * time.Second / TPS
+}
+
+// FormatTicks formats a number of ticks as a time, like 01:23.456
+func FormatTicks(ticks int) string {
+	ms := int(TicksToDuration(ticks).Milliseconds())
+	return fmt.Sprintf("%02d:%02d.%03d", ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// LevelInfo describes a level in the level catalogue, values come from the Tiled map properties
+type LevelInfo struct {
+	ID         string
+	Path       string
+	Title      string
+	Order      int
+	Difficulty string
+	Author     string
+	// medal times, the total of all laps in seconds (0 is no medal)
+	Gold, Silver, Bronze float64
+}
+
+// Medal returns the medal of a race which took ticks, empty when there is none
+func (l LevelInfo) Medal(ticks int) string {
+	t := TicksToDuration(ticks).Seconds()
+	for _, m := range []struct {
+		name string
+		time float64
+	}{{"gold", l.Gold}, {"silver", l.Silver}, {"bronze", l.Bronze}} {
+		if m.time > 0 && t <= m.time {
+			return m.name
+		}
+	}
+	return ""
 }
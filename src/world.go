@@ -0,0 +1,213 @@
+package src
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	com "moonlander/src/components"
+	sha "moonlander/src/shared"
+
+	"github.com/hajimehoshi/ebiten"
+	"golang.org/x/image/math/f64"
+)
+
+// World is a loaded level, it owns its objects, level properties, camera and the lists of the game loop,
+// so worlds don't share state and can exist side by side
+type World struct {
+	LP             sha.LevelProperties
+	Info           sha.LevelInfo
+	Seed           int64
+	rand           *rand.Rand
+	replay         *Replay
+	hud            *com.TextBlock
+	finishTick     int
+	splitTicks     int
+	camera         Camera
+	image          *ebiten.Image
+	player         *com.Player
+	DrawWorldList  []com.GameObject
+	DrawScreenList []com.GameObject
+	HitAbleList    []com.GameObject
+	UpdateList     []com.GameObject
+	CollideList    []com.GameObject
+	checkpoints    []*com.Checkpoint
+	finish         *com.Finish
+	spawners       []*com.Spawner
+	hitHash        *com.SpatialHash
+	contacts       *com.ContactManager
+	objectTypes    []ObjectType
+	// entities by handle, and the queues applied between ticks
+	nextHandle   Handle
+	entities     map[Handle]com.GameObject
+	handles      map[com.GameObject]Handle
+	spawnQueue   []pendingSpawn
+	destroyQueue []Handle
+	// Tiled layer of the drawn objects, and of the objects being added
+	// (after loading a layer above all Tiled layers)
+	drawLayer map[com.GameObject]int
+	loadLayer int
+	// the ghost of the best lap, and the trace of the lap being flown
+	ghost     *com.Ghost
+	bestLap   *BestLap
+	lapTrace  []com.GhostFrame
+	laps      int
+	hideGhost bool
+}
+
+// NewWorld constructor, an empty world for a level, LoadLevel fills it.
+// All randomness in the world comes from seed, so the same seed and inputs give the same run
+func NewWorld(info sha.LevelInfo, seed int64) *World {
+	return &World{
+		Info:      info,
+		Seed:      seed,
+		rand:      rand.New(rand.NewSource(seed)),
+		camera:    Camera{ViewPort: f64.Vec2{sha.ScreenWidth, sha.ScreenHeight}},
+		entities:  make(map[Handle]com.GameObject),
+		handles:   make(map[com.GameObject]Handle),
+		drawLayer: make(map[com.GameObject]int),
+	}
+}
+
+// Update proceeds the world one tick, updates and collides all objects and moves the camera
+func (w *World) Update(screen *ebiten.Image) error {
+	w.LP.Clock.Step()
+
+	// loop through update list and collide list
+	for _, i := range w.UpdateList {
+		i.Update(screen)
+	}
+	// only collide with the hitables near the collider
+	w.hitHash.Update()
+	for _, i := range w.CollideList {
+		i.Collide(w.hitHash.Query(i))
+	}
+	w.contacts.Update(w.CollideList)
+	w.recordLap()
+	if w.finishTick == 0 && w.Finished() {
+		w.finishTick = w.LP.Clock.Tick
+	}
+
+	// entities spawned or destroyed during this tick
+	w.applyQueues()
+
+	// update camera
+	return w.camera.Update(w.player)
+}
+
+// Draw draws the world through its camera, and the gui on top
+func (w *World) Draw(screen *ebiten.Image) {
+	// objects can change z while playing
+	if !sort.SliceIsSorted(w.DrawWorldList, w.drawBefore) {
+		sort.SliceStable(w.DrawWorldList, w.drawBefore)
+	}
+	// draw in world
+	for _, i := range w.DrawWorldList {
+		i.Draw(w.image)
+	}
+	// render world in camera
+	w.camera.Render(w.image, screen)
+
+	// draw on screen (gui)
+	for _, i := range w.DrawScreenList {
+		i.Draw(screen)
+	}
+}
+
+// Finished returns true when the race is done
+func (w *World) Finished() bool {
+	return w.finish != nil && w.finish.Finished()
+}
+
+// JustFinished returns true in the tick the race is done
+func (w *World) JustFinished() bool {
+	return w.finishTick != 0 && w.finishTick == w.LP.Clock.Tick
+}
+
+// TicksSinceFinish returns the ticks since the race is done, 0 when it isn't
+func (w *World) TicksSinceFinish() int {
+	if w.finishTick == 0 {
+		return 0
+	}
+	return w.LP.Clock.Tick - w.finishTick
+}
+
+// SetLeaderboard sets the records shown in the hud at the end of the race
+func (w *World) SetLeaderboard(s string) {
+	if w.hud != nil {
+		w.hud.SetLeaderboard(s)
+	}
+}
+
+// SetBestSplits sets the split times of the best lap to compare with, ticks is the time of that lap
+func (w *World) SetBestSplits(ticks int, splits []int) {
+	w.splitTicks = ticks
+	w.LP.BestSplits = splits
+}
+
+// Replay returns the recording of the run so far, nil when the world plays a replay
+func (w *World) Replay() *Replay {
+	return w.replay
+}
+
+// addDrawable adds an object to the draw list, in a Tiled layer
+func (w *World) addDrawable(o com.GameObject, layer int) {
+	w.DrawWorldList = append(w.DrawWorldList, o)
+	w.drawLayer[o] = layer
+}
+
+// drawBefore sorts the draw list on z, then on Tiled layer order, the sort is stable
+// so objects in the same z and layer keep the order in which they were added
+func (w *World) drawBefore(i, j int) bool {
+	a, b := w.DrawWorldList[i], w.DrawWorldList[j]
+	za, zb := getZ(a), getZ(b)
+	if za != zb {
+		return za < zb
+	}
+	return w.drawLayer[a] < w.drawLayer[b]
+}
+
+func getZ(o com.GameObject) int {
+	if z, ok := o.(com.ZOrdered); ok {
+		return z.GetZ()
+	}
+	return 0
+}
+
+// Raycast returns the first hitable object on a layer in mask, hit by a ray from origin in direction dir
+func (w *World) Raycast(origin, dir com.Vector, maxDist float64, mask com.Layer) (com.RayHit, bool) {
+	return w.hitHash.Raycast(origin, dir, maxDist, mask)
+}
+
+// QueryRect returns the hitable objects on a layer in mask which overlap a rect
+func (w *World) QueryRect(x, y, width, height int, mask com.Layer) []com.GameObject {
+	return w.hitHash.QueryRect(x, y, width, height, mask)
+}
+
+// QueryCircle returns the hitable objects on a layer in mask which overlap a circle
+func (w *World) QueryCircle(center com.Vector, radius float64, mask com.Layer) []com.GameObject {
+	return w.hitHash.QueryCircle(center, radius, mask)
+}
+
+func (w *World) printObjects() {
+	fmt.Println("\nDrawWorldList")
+	for _, o := range w.DrawWorldList {
+		fmt.Println(o.GetInfo())
+	}
+	fmt.Println("\nDrawScreenList")
+	for _, o := range w.DrawScreenList {
+		fmt.Println(o.GetInfo())
+	}
+	fmt.Println("\nHitAbleList")
+	for _, o := range w.HitAbleList {
+		fmt.Println(o.GetInfo())
+	}
+	fmt.Println("\nUpdateList")
+	for _, o := range w.UpdateList {
+		fmt.Println(o.GetInfo())
+	}
+	fmt.Println("\nCollideList")
+	for _, o := range w.CollideList {
+		fmt.Println(o.GetInfo())
+	}
+}